@@ -11,6 +11,9 @@ AUTH_CLIENT_SECRET=clientsecret
 # stager task persistent store
 TASK_DB_REDIS_DATA=/tmp/data
 
+# worker state (checkpoint journals) shared by workers
+WORKER_STATE_VOL=/tmp/worker-state
+
 # configuration files
 API_CONFIG=./config/api-server.yml
 WORKER_CONFIG=./config/worker.yml
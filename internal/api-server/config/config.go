@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/spf13/viper"
 
+	"github.com/dccn-tg/dr-data-stager/pkg/tasks"
 	"github.com/dccn-tg/dr-data-stager/pkg/utility"
 )
 
@@ -23,6 +25,17 @@ type Configuration struct {
 	RdrGateway utility.RdrGatewayConfig
 	PpmForm    utility.PpmFormConfig
 	Dacs       map[string]string
+	// JobRetention is the duration in seconds for which a finished job and its transfer
+	// manifest are kept; 0 for `tasks.DefaultRetention`.
+	JobRetention int64
+}
+
+// Retention returns the duration for which a finished job and its transfer manifest are kept.
+func (c Configuration) Retention() time.Duration {
+	if c.JobRetention <= 0 {
+		return tasks.DefaultRetention
+	}
+	return time.Duration(c.JobRetention) * time.Second
 }
 
 // LoadConfig reads configuration file `cpath` and returns the
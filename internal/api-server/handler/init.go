@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/dccn-tg/dr-data-stager/internal/api-server/config"
+	ppath "github.com/dccn-tg/dr-data-stager/pkg/path"
 	"github.com/dccn-tg/dr-data-stager/pkg/swagger/server/models"
 	"github.com/dccn-tg/dr-data-stager/pkg/swagger/server/restapi/operations"
 	"github.com/dccn-tg/dr-data-stager/pkg/tasks"
@@ -37,6 +38,9 @@ var (
 
 	// RetryIntervalSeconds
 	RetryIntervalSeconds int = 1
+
+	// MaxJobFilesPerPage is the maximum number of file records returned in one query.
+	MaxJobFilesPerPage int64 = 1000
 )
 
 // Error code definitions.
@@ -103,6 +107,64 @@ func GetJob(ctx context.Context, inspector *asynq.Inspector) func(params operati
 	}
 }
 
+// GetJobFiles retrieves the transfer records of files processed by the job.
+func GetJobFiles(ctx context.Context, inspector *asynq.Inspector, rdb *redis.Client) func(params operations.GetJobIDFilesParams, principal *models.Principal) middleware.Responder {
+	return func(params operations.GetJobIDFilesParams, principal *models.Principal) middleware.Responder {
+		id := params.ID
+
+		// retrieve task from the queue to check the job owner
+		_, err := findTask(inspector, id, principal)
+		if errors.Is(err, asynq.ErrTaskNotFound) {
+			return operations.NewGetJobIDFilesNotFound().WithPayload(
+				fmt.Sprintf("job %s doesn't exist or not owned by user %s", id, *principal),
+			)
+		}
+
+		if err != nil {
+			log.Errorf("[%s]: %s", id, err)
+			return operations.NewGetJobIDFilesInternalServerError().WithPayload(
+				&models.ResponseBody500{
+					ErrorMessage: err.Error(),
+					ExitCode:     JobDataError,
+				},
+			)
+		}
+
+		offset := int64(0)
+		if params.Offset != nil && *params.Offset > 0 {
+			offset = *params.Offset
+		}
+
+		limit := MaxJobFilesPerPage
+		if params.Limit != nil && *params.Limit > 0 && *params.Limit < limit {
+			limit = *params.Limit
+		}
+
+		status := ""
+		if params.Status != nil {
+			status = *params.Status
+		}
+
+		total, files, err := getJobFiles(ctx, rdb, id, status, offset, limit)
+		if err != nil {
+			log.Errorf("[%s] cannot retrieve transfer manifest: %s", id, err)
+			return operations.NewGetJobIDFilesInternalServerError().WithPayload(
+				&models.ResponseBody500{
+					ErrorMessage: err.Error(),
+					ExitCode:     JobDataError,
+				},
+			)
+		}
+
+		return operations.NewGetJobIDFilesOK().WithPayload(
+			&models.ResponseBodyJobFiles{
+				Total: total,
+				Files: files,
+			},
+		)
+	}
+}
+
 // RescheduleJob
 func RescheduleJob(ctx context.Context, client *asynq.Client, inspector *asynq.Inspector) func(params operations.PutJobScheduledIDParams, principal *models.Principal) middleware.Responder {
 	return func(params operations.PutJobScheduledIDParams, principal *models.Principal) middleware.Responder {
@@ -137,7 +199,7 @@ func RescheduleJob(ctx context.Context, client *asynq.Client, inspector *asynq.I
 		// delete task and enqueue the task with same ID, payload and options
 		// let the enqueued task to be processed in 30 seconds.
 		nt := asynq.NewTask(
-			tasks.TypeStager,
+			taskInfo.Type,
 			taskInfo.Payload,
 			asynq.MaxRetry(taskInfo.MaxRetry),
 			asynq.Retention(taskInfo.Retention),
@@ -190,7 +252,7 @@ func RescheduleJob(ctx context.Context, client *asynq.Client, inspector *asynq.I
 }
 
 // DeleteJob cancels the job.
-func DeleteJob(ctx context.Context, inspector *asynq.Inspector) func(params operations.DeleteJobIDParams, principal *models.Principal) middleware.Responder {
+func DeleteJob(ctx context.Context, inspector *asynq.Inspector, rdb *redis.Client) func(params operations.DeleteJobIDParams, principal *models.Principal) middleware.Responder {
 
 	return func(params operations.DeleteJobIDParams, principal *models.Principal) middleware.Responder {
 
@@ -244,6 +306,11 @@ func DeleteJob(ctx context.Context, inspector *asynq.Inspector) func(params oper
 
 		log.Infof("[%s] task deleted", id)
 
+		// remove transfer manifest of the task
+		if err := rdb.Del(ctx, tasks.ManifestKey(id)).Err(); err != nil {
+			log.Warnf("[%s] cannot delete transfer manifest: %s", id, err)
+		}
+
 		jinfo, err := composeResponseBodyJobInfo(taskInfo)
 		if err != nil {
 			log.Errorf("%s", err)
@@ -398,7 +465,7 @@ func GetDacByProject(ctx context.Context, cfg config.Configuration) func(params
 }
 
 // NewJobs registers the incoming transfer request as multiple stager jobs in the queue.
-func NewJobs(ctx context.Context, client *asynq.Client, rdb *redis.Client) func(params operations.PostJobsParams, principal *models.Principal) middleware.Responder {
+func NewJobs(ctx context.Context, client *asynq.Client, rdb *redis.Client, retention time.Duration) func(params operations.PostJobsParams, principal *models.Principal) middleware.Responder {
 	return func(params operations.PostJobsParams, principal *models.Principal) middleware.Responder {
 
 		submitted := []*models.JobInfo{}
@@ -409,7 +476,7 @@ func NewJobs(ctx context.Context, client *asynq.Client, rdb *redis.Client) func(
 				continue
 			}
 
-			taskInfo, err := enqueueStagerTask(ctx, client, jdata, rdb)
+			taskInfo, err := enqueueStagerTask(ctx, client, jdata, rdb, retention)
 			if err != nil {
 				log.Errorf("cannot enqueue task: %s", err)
 				continue
@@ -451,7 +518,7 @@ func NewJobs(ctx context.Context, client *asynq.Client, rdb *redis.Client) func(
 }
 
 // NewJob registers the incoming transfer request as a new stager job in the queue.
-func NewJob(ctx context.Context, client *asynq.Client, rdb *redis.Client) func(params operations.PostJobParams, principal *models.Principal) middleware.Responder {
+func NewJob(ctx context.Context, client *asynq.Client, rdb *redis.Client, retention time.Duration) func(params operations.PostJobParams, principal *models.Principal) middleware.Responder {
 	return func(params operations.PostJobParams, principal *models.Principal) middleware.Responder {
 
 		// check if job owner matches the authenticated principal
@@ -464,7 +531,7 @@ func NewJob(ctx context.Context, client *asynq.Client, rdb *redis.Client) func(p
 			)
 		}
 
-		taskInfo, err := enqueueStagerTask(ctx, client, params.Data, rdb)
+		taskInfo, err := enqueueStagerTask(ctx, client, params.Data, rdb, retention)
 		if err != nil {
 			log.Errorf("cannot enqueue task: %s", err)
 			return operations.NewPostJobInternalServerError().WithPayload(
@@ -667,8 +734,9 @@ func getTasksOwnedBy(inspector *asynq.Inspector, username string) []*models.JobI
 	return tasks
 }
 
-// enqueueStagerTask creates a new stager task in the asynq queue.
-func enqueueStagerTask(ctx context.Context, client *asynq.Client, job *models.JobData, rdb *redis.Client) (*asynq.TaskInfo, error) {
+// enqueueStagerTask creates a new stager task in the asynq queue.  The finished task and its
+// transfer manifest are kept for the duration of `retention`.
+func enqueueStagerTask(ctx context.Context, client *asynq.Client, job *models.JobData, rdb *redis.Client, retention time.Duration) (*asynq.TaskInfo, error) {
 	// set default job timeout (24 hours)
 	timeout := job.Timeout
 	if timeout <= 0 {
@@ -681,17 +749,76 @@ func enqueueStagerTask(ctx context.Context, client *asynq.Client, job *models.Jo
 		timeoutNp = 3600
 	}
 
-	t, err := tasks.NewStagerTask(
-		*job.Title,
-		*job.DrUser,
-		job.DrPass,
-		*job.DstURL,
-		*job.SrcURL,
-		*job.StagerUser,
-		job.StagerUserEmail.String(),
-		timeout,
-		timeoutNp,
-	)
+	// reject invalid filter rules before the job is scheduled
+	filter := ppath.Filter{
+		Include: job.Include,
+		Exclude: job.Exclude,
+		MinSize: job.MinSize,
+		MaxSize: job.MaxSize,
+	}
+	if err := filter.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid filter: %s", err)
+	}
+
+	if _, err := ppath.ParseSymlinkPolicy(job.Symlinks); err != nil {
+		return nil, err
+	}
+
+	if _, err := ppath.ParseCompareStrategy(job.Compare); err != nil {
+		return nil, err
+	}
+
+	if _, err := ppath.ParseConflictPolicy(job.Conflict); err != nil {
+		return nil, err
+	}
+
+	metadata := make([]tasks.Metadata, 0, len(job.Metadata))
+	for _, m := range job.Metadata {
+		md := tasks.Metadata{
+			Attribute: *m.Attribute,
+			Value:     *m.Value,
+			Unit:      m.Unit,
+		}
+		if err := md.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid metadata: %s", err)
+		}
+		metadata = append(metadata, md)
+	}
+
+	// the plan-only job has the same payload as the stager job
+	newTask := tasks.NewStagerTask
+	if job.PlanOnly {
+		newTask = tasks.NewPlanTask
+	}
+
+	t, err := newTask(tasks.StagerPayload{
+		Title:             *job.Title,
+		DrUser:            *job.DrUser,
+		DrPass:            job.DrPass,
+		DstURL:            *job.DstURL,
+		SrcURL:            *job.SrcURL,
+		StagerUser:        *job.StagerUser,
+		StagerUserEmail:   job.StagerUserEmail.String(),
+		Timeout:           timeout,
+		TimeoutNoprogress: timeoutNp,
+		Retention:         int64(retention / time.Second),
+		ContinueOnError:   job.ContinueOnError,
+		Verify:            job.Verify,
+		Mirror:            job.Mirror,
+		MaxDeletions:      job.MaxDeletions,
+		Include:           job.Include,
+		Exclude:           job.Exclude,
+		MinSize:           job.MinSize,
+		MaxSize:           job.MaxSize,
+		Symlinks:          job.Symlinks,
+		BandwidthLimit:    job.BandwidthLimit,
+		Metadata:          metadata,
+		BundleThreshold:   job.BundleThreshold,
+		BundleSize:        job.BundleSize,
+		Unpack:            job.Unpack,
+		Compare:           job.Compare,
+		Conflict:          job.Conflict,
+	})
 
 	if err != nil {
 		return nil, err
@@ -706,12 +833,147 @@ func enqueueStagerTask(ctx context.Context, client *asynq.Client, job *models.Jo
 		ctx,
 		t,
 		asynq.TaskID(fmt.Sprintf("%s.%d", *job.StagerUser, tid)),
-		asynq.Retention(2*24*time.Hour),
+		asynq.Retention(retention),
 		asynq.MaxRetry(4),
 		asynq.Timeout(time.Duration(timeout)*time.Second), // this set the hard timeout
 	)
 }
 
+// getJobFiles retrieves the transfer records of the task `id` from the redis list.  Only records
+// with action `status` are returned if `status` is not empty.  It returns the total number of
+// matching records and the matching records in the range of `offset` and `limit`.
+func getJobFiles(ctx context.Context, rdb *redis.Client, id, status string, offset, limit int64) (int64, []*models.JobFile, error) {
+
+	key := tasks.ManifestKey(id)
+
+	files := []*models.JobFile{}
+
+	toJobFile := func(data string) (*models.JobFile, error) {
+		var e tasks.ManifestEntry
+		if err := json.Unmarshal([]byte(data), &e); err != nil {
+			return nil, err
+		}
+		return &models.JobFile{
+			Path:     &e.Path,
+			DstPath:  e.DstPath,
+			Size:     e.Size,
+			Checksum: e.Checksum,
+			Link:     e.Link,
+			Action:   &e.Action,
+			Error:    e.Error,
+		}, nil
+	}
+
+	// without filter, the range is applied to the redis list directly.
+	if status == "" {
+		total, err := rdb.LLen(ctx, key).Result()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		data, err := rdb.LRange(ctx, key, offset, offset+limit-1).Result()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		for _, d := range data {
+			f, err := toJobFile(d)
+			if err != nil {
+				log.Warnf("[%s] skip invalid manifest entry: %s", id, err)
+				continue
+			}
+			files = append(files, f)
+		}
+		return total, files, nil
+	}
+
+	// with filter, the list is scanned in chunks to count and collect the matching records.
+	total := int64(0)
+	chunk := int64(1000)
+	for start := int64(0); ; start += chunk {
+		data, err := rdb.LRange(ctx, key, start, start+chunk-1).Result()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		for _, d := range data {
+			f, err := toJobFile(d)
+			if err != nil {
+				log.Warnf("[%s] skip invalid manifest entry: %s", id, err)
+				continue
+			}
+
+			if *f.Action != status {
+				continue
+			}
+
+			if total >= offset && total < offset+limit {
+				files = append(files, f)
+			}
+			total++
+		}
+
+		if int64(len(data)) < chunk {
+			break
+		}
+	}
+
+	return total, files, nil
+}
+
+// composeJobPlan converts the plan result of a plan-only task into the `models.JobPlan`.
+// It returns `nil` if the task is not a plan-only task.
+func composeJobPlan(plan *tasks.PlanResult) *models.JobPlan {
+	if plan == nil {
+		return nil
+	}
+
+	sample := make([]*models.JobFile, 0, len(plan.Sample))
+	for i := range plan.Sample {
+		e := &plan.Sample[i]
+		sample = append(sample, &models.JobFile{
+			Path:    &e.Path,
+			DstPath: e.DstPath,
+			Size:    e.Size,
+			Action:  &e.Action,
+		})
+	}
+
+	return &models.JobPlan{
+		Copy:      &plan.Copy,
+		Overwrite: &plan.Overwrite,
+		Skip:      &plan.Skip,
+		Delete:    &plan.Delete,
+		Bytes:     &plan.Bytes,
+		Sample:    sample,
+	}
+}
+
+// composeJobConflicts converts the numbers of files handled by the conflict policy into
+// the `models.JobConflicts`.
+func composeJobConflicts(c tasks.ConflictResult) *models.JobConflicts {
+	return &models.JobConflicts{
+		Overwritten: &c.Overwritten,
+		Skipped:     &c.Skipped,
+		Renamed:     &c.Renamed,
+		Failed:      &c.Failed,
+	}
+}
+
+// composeJobMetadata converts the metadata in the task payload into `models.JobMetadata`.
+func composeJobMetadata(metadata []tasks.Metadata) []*models.JobMetadata {
+	jmetadata := make([]*models.JobMetadata, 0, len(metadata))
+	for i := range metadata {
+		m := &metadata[i]
+		jmetadata = append(jmetadata, &models.JobMetadata{
+			Attribute: &m.Attribute,
+			Value:     &m.Value,
+			Unit:      m.Unit,
+		})
+	}
+	return jmetadata
+}
+
 // composeResponseBodyJobInfo wraps the data structure of `asynq.TaskInfo` into `models.JobInfo`.
 func composeResponseBodyJobInfo(task *asynq.TaskInfo) (*models.JobInfo, error) {
 
@@ -751,6 +1013,23 @@ func composeResponseBodyJobInfo(task *asynq.TaskInfo) (*models.JobInfo, error) {
 			DstURL:            &j.DstURL,
 			Timeout:           j.Timeout,
 			TimeoutNoprogress: j.TimeoutNoprogress,
+			ContinueOnError:   j.ContinueOnError,
+			Verify:            j.Verify,
+			Mirror:            j.Mirror,
+			MaxDeletions:      j.MaxDeletions,
+			Include:           j.Include,
+			Exclude:           j.Exclude,
+			MinSize:           j.MinSize,
+			MaxSize:           j.MaxSize,
+			Symlinks:          j.Symlinks,
+			BandwidthLimit:    j.BandwidthLimit,
+			Metadata:          composeJobMetadata(j.Metadata),
+			BundleThreshold:   j.BundleThreshold,
+			BundleSize:        j.BundleSize,
+			Unpack:            j.Unpack,
+			Compare:           j.Compare,
+			Conflict:          j.Conflict,
+			PlanOnly:          task.Type == tasks.TypePlan,
 		},
 		Timestamps: &models.JobTimestamps{
 			CreatedAt:     &createdAt,
@@ -761,12 +1040,22 @@ func composeResponseBodyJobInfo(task *asynq.TaskInfo) (*models.JobInfo, error) {
 		Status: &models.JobStatus{
 			Status: &jStatus,
 			Progress: &models.JobProgress{
-				Total:     &jResult.Progress.Total,
-				Processed: &jResult.Progress.Processed,
-				Failed:    &jResult.Progress.Failed,
+				Total:          &jResult.Progress.Total,
+				Processed:      &jResult.Progress.Processed,
+				Failed:         &jResult.Progress.Failed,
+				TotalBytes:     jResult.Progress.TotalBytes,
+				ProcessedBytes: jResult.Progress.ProcessedBytes,
+				Rate:           jResult.Progress.Rate,
+				Eta:            jResult.Progress.ETA,
+				Provisional:    jResult.Progress.Provisional,
 			},
-			Error:    &task.LastErr,
-			Attempts: &attempts,
+			Error:        &task.LastErr,
+			Attempts:     &attempts,
+			Deleted:      jResult.Deleted,
+			DeletedPaths: jResult.DeletedPaths,
+			SkippedLinks: jResult.SkippedLinks,
+			Plan:         composeJobPlan(jResult.Plan),
+			Conflicts:    composeJobConflicts(jResult.Conflicts),
 		},
 	}, nil
 }
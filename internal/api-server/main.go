@@ -87,7 +87,7 @@ func main() {
 		log.Fatalf("cannot parse redis URL: %s", err)
 	}
 
-	// initialize another redis client for incremental taskId generation
+	// initialize another redis client for incremental taskId generation and transfer manifests
 	rdbOpts, _ := redis.ParseURL(*redisURL)
 	rdb4tid := redis.NewClient(rdbOpts)
 	defer rdb4tid.Close()
@@ -202,9 +202,10 @@ func main() {
 
 	api.GetPingHandler = operations.GetPingHandlerFunc(handler.GetPing(cfg))
 	api.GetJobIDHandler = operations.GetJobIDHandlerFunc(handler.GetJob(ctx, inspector))
-	api.DeleteJobIDHandler = operations.DeleteJobIDHandlerFunc(handler.DeleteJob(ctx, inspector))
-	api.PostJobHandler = operations.PostJobHandlerFunc(handler.NewJob(ctx, client, rdb4tid))
-	api.PostJobsHandler = operations.PostJobsHandlerFunc(handler.NewJobs(ctx, client, rdb4tid))
+	api.GetJobIDFilesHandler = operations.GetJobIDFilesHandlerFunc(handler.GetJobFiles(ctx, inspector, rdb4tid))
+	api.DeleteJobIDHandler = operations.DeleteJobIDHandlerFunc(handler.DeleteJob(ctx, inspector, rdb4tid))
+	api.PostJobHandler = operations.PostJobHandlerFunc(handler.NewJob(ctx, client, rdb4tid, cfg.Retention()))
+	api.PostJobsHandler = operations.PostJobsHandlerFunc(handler.NewJobs(ctx, client, rdb4tid, cfg.Retention()))
 	api.PutJobScheduledIDHandler = operations.PutJobScheduledIDHandlerFunc(handler.RescheduleJob(ctx, client, inspector))
 	api.GetJobsHandler = operations.GetJobsHandlerFunc(handler.GetJobs(ctx, inspector))
 	api.GetDirHandler = operations.GetDirHandlerFunc(handler.ListDir(ctx))
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/cyverse/go-irodsclient/fs"
+	irods_fs "github.com/cyverse/go-irodsclient/irods/fs"
+	"github.com/cyverse/go-irodsclient/irods/types"
+	"github.com/dccn-tg/dr-data-stager/pkg/dr"
+	ppath "github.com/dccn-tg/dr-data-stager/pkg/path"
+	log "github.com/dccn-tg/tg-toolset-golang/pkg/logger"
+)
+
+// partialPath returns the path of the hidden temporary file to which the file `p` is
+// transferred by the task, i.e. `.<name>.stager-<taskID>.part` in the same directory.
+func partialPath(p string) string {
+	return ppath.TempPath(p, taskID, ppath.PartialSuffix)
+}
+
+// partialEntry is the record of a temporary file created by the task.
+type partialEntry struct {
+	Path string         `json:"path"`
+	Type ppath.PathType `json:"type"`
+}
+
+// partialLog records the temporary files created by the task in the state directory of the
+// task, so that the temporary files left behind by an aborted attempt are removed by the
+// next attempt.
+//
+// A `nil` log is valid; it discards all records and removes nothing.
+type partialLog struct {
+	mu    sync.Mutex
+	f     *os.File
+	enc   *json.Encoder
+	stale map[string]partialEntry
+}
+
+// partials is the log of temporary files of the task.
+var partials *partialLog
+
+// openPartialLog loads the temporary files of previous attempts from the state directory
+// `dir`, and opens the log for appending new records.  It returns a `nil` log when `dir`
+// is an empty string.
+func openPartialLog(dir string) (*partialLog, error) {
+	if dir == "" {
+		return nil, nil
+	}
+
+	f, err := os.OpenFile(filepath.Join(dir, "partial"), os.O_CREATE|os.O_RDWR|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &partialLog{
+		f:     f,
+		enc:   json.NewEncoder(f),
+		stale: make(map[string]partialEntry),
+	}
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e partialEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		l.stale[e.Path] = e
+	}
+
+	if err := scanner.Err(); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if err := terminateLine(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// Add records the temporary file `p` of type `t` before it is created.  A temporary file
+// left behind by a previous attempt is reused, it is no longer stale.
+func (l *partialLog) Add(p string, t ppath.PathType) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.stale[p]; ok {
+		delete(l.stale, p)
+		return
+	}
+
+	if err := l.enc.Encode(partialEntry{Path: p, Type: t}); err != nil {
+		log.Errorf("[%s] fail to record temporary file %s: %s", taskID, p, err)
+	}
+}
+
+// Clean removes the stale temporary files left behind by previous attempts.  Unless `all`
+// is set, partially downloaded files that can be resumed are kept for the transfers of this
+// attempt.
+func (l *partialLog) Clean(ctx context.Context, all bool) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ifs := ctx.Value(dr.KeyFilesystem).(*fs.FileSystem)
+
+	for p, e := range l.stale {
+		var err error
+		switch e.Type {
+		case ppath.TypeIrods:
+			if ifs.ExistsFile(p) {
+				err = ifs.RemoveFile(p, true)
+			}
+		default:
+			status := irods_fs.GetDataObjectTransferStatusFilePath(p)
+			if _, serr := os.Stat(status); serr == nil && !all {
+				continue
+			}
+			err = os.Remove(p)
+			if err == nil || os.IsNotExist(err) {
+				err = os.Remove(status)
+			}
+			if os.IsNotExist(err) {
+				err = nil
+			}
+		}
+
+		if err != nil {
+			log.Warnf("[%s] cannot remove stale temporary file %s: %s", taskID, p, err)
+			continue
+		}
+		log.Debugf("[%s] removed stale temporary file %s", taskID, p)
+		delete(l.stale, p)
+	}
+}
+
+// Close closes the log file.
+func (l *partialLog) Close() error {
+	if l == nil {
+		return nil
+	}
+	return l.f.Close()
+}
+
+// managedAttributes are the metadata attributes set by the stager to describe the data of a
+// data object, they are not carried over from a replaced data object.
+var managedAttributes = map[string]bool{
+	ppath.ModTimeAttribute: true,
+	ppath.ModeAttribute:    true,
+	ppath.SymlinkAttribute: true,
+}
+
+// replaceObject renames the iRODS data object `tmp` to `dst`.  As iRODS doesn't rename a data
+// object over an existing one, an existing data object `dst` is first renamed aside, and only
+// removed after `tmp` is renamed into place; it is restored if the rename fails.  The user
+// metadata of the replaced data object is copied over to `tmp`.
+func replaceObject(ctx context.Context, tmp, dst string) error {
+
+	ifs := ctx.Value(dr.KeyFilesystem).(*fs.FileSystem)
+
+	if !ifs.ExistsFile(dst) {
+		return ifs.RenameFileToFile(tmp, dst)
+	}
+
+	metas, err := ifs.ListMetadata(dst)
+	if err != nil {
+		return fmt.Errorf("cannot get metadata of %s: %w", dst, err)
+	}
+
+	// the temporary data object may be left behind by a previous attempt with
+	// the metadata already copied.
+	tmetas, err := ifs.ListMetadata(tmp)
+	if err != nil {
+		tmetas = nil
+	}
+
+	for _, m := range carriedMetadata(metas, tmetas) {
+		if err := ifs.AddMetadata(tmp, m.Name, m.Value, m.Units); err != nil {
+			return fmt.Errorf("cannot copy metadata of %s: %w", dst, err)
+		}
+	}
+
+	old := ppath.TempPath(dst, taskID, ppath.ReplacedSuffix)
+	partials.Add(old, ppath.TypeIrods)
+
+	if err := ifs.RenameFileToFile(dst, old); err != nil {
+		return err
+	}
+
+	if err := ifs.RenameFileToFile(tmp, dst); err != nil {
+		if rerr := ifs.RenameFileToFile(old, dst); rerr != nil {
+			log.Errorf("[%s] cannot restore %s from %s: %s", taskID, dst, old, rerr)
+		}
+		return err
+	}
+
+	return ifs.RemoveFile(old, true)
+}
+
+// carriedMetadata returns the user metadata `metas` of a replaced data object to be copied
+// over to its replacement, which already has the metadata `existing`.
+func carriedMetadata(metas, existing []*types.IRODSMeta) []*types.IRODSMeta {
+
+	copied := make(map[[3]string]bool)
+	for _, m := range existing {
+		copied[[3]string{m.Name, m.Value, m.Units}] = true
+	}
+
+	var carried []*types.IRODSMeta
+	for _, m := range metas {
+		if managedAttributes[m.Name] || copied[[3]string{m.Name, m.Value, m.Units}] {
+			continue
+		}
+		carried = append(carried, m)
+	}
+	return carried
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/cyverse/go-irodsclient/fs"
+	irods_fs "github.com/cyverse/go-irodsclient/irods/fs"
+	"github.com/cyverse/go-irodsclient/irods/types"
+	"github.com/dccn-tg/dr-data-stager/pkg/dr"
+	ppath "github.com/dccn-tg/dr-data-stager/pkg/path"
+)
+
+// touch creates the files `paths` with some content.
+func touch(t *testing.T, paths ...string) {
+	for _, p := range paths {
+		if err := os.WriteFile(p, []byte("data"), 0644); err != nil {
+			t.Fatalf("%s\n", err)
+		}
+	}
+}
+
+// exists checks whether the local file `p` exists.
+func exists(p string) bool {
+	_, err := os.Stat(p)
+	return err == nil
+}
+
+func TestPartialLog(t *testing.T) {
+
+	defer func(id string) { taskID = id }(taskID)
+	taskID = "a1b2"
+
+	// only local temporary files are cleaned, the filesystem is never used.
+	ctx := context.WithValue(context.Background(), dr.KeyFilesystem, (*fs.FileSystem)(nil))
+
+	state := t.TempDir()
+	data := t.TempDir()
+
+	// the log is disabled without a state directory.
+	if l, err := openPartialLog(""); l != nil || err != nil {
+		t.Fatalf("expected a nil log, got %v, %v", l, err)
+	}
+
+	reused := partialPath(filepath.Join(data, "reused.dat"))
+	stale := partialPath(filepath.Join(data, "stale.dat"))
+	resumable := partialPath(filepath.Join(data, "resumable.dat"))
+	status := irods_fs.GetDataObjectTransferStatusFilePath(resumable)
+
+	// first attempt, aborted with the temporary files left behind.
+	l, err := openPartialLog(state)
+	if err != nil {
+		t.Fatalf("%s\n", err)
+	}
+	for _, p := range []string{reused, stale, resumable} {
+		l.Add(p, ppath.TypeFileSystem)
+	}
+	touch(t, reused, stale, resumable, status)
+	l.Close()
+
+	// second attempt, with a truncated record at the end of the log.
+	f, err := os.OpenFile(filepath.Join(state, "partial"), os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatalf("%s\n", err)
+	}
+	f.WriteString(`{"path":"` + data)
+	f.Close()
+
+	l, err = openPartialLog(state)
+	if err != nil {
+		t.Fatalf("%s\n", err)
+	}
+
+	var got []string
+	for p := range l.stale {
+		got = append(got, p)
+	}
+	sort.Strings(got)
+	expected := []string{resumable, reused, stale}
+	sort.Strings(expected)
+	if strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Errorf("stale: expected %v, got %v", expected, got)
+	}
+
+	// the temporary file reused by this attempt is no longer stale.
+	l.Add(reused, ppath.TypeFileSystem)
+	if _, ok := l.stale[reused]; ok {
+		t.Errorf("reused temporary file still stale")
+	}
+
+	// the resumable download is kept for this attempt.
+	l.Clean(ctx, false)
+	if !exists(reused) || exists(stale) || !exists(resumable) || !exists(status) {
+		t.Errorf("clean: unexpected files left: reused %t, stale %t, resumable %t, status %t",
+			exists(reused), exists(stale), exists(resumable), exists(status))
+	}
+
+	// the resumable download is removed with its transfer status at the end of the task.
+	l.Clean(ctx, true)
+	if !exists(reused) || exists(resumable) || exists(status) || len(l.stale) != 0 {
+		t.Errorf("clean all: unexpected files left: reused %t, resumable %t, status %t",
+			exists(reused), exists(resumable), exists(status))
+	}
+	l.Close()
+}
+
+func TestCarriedMetadata(t *testing.T) {
+
+	meta := func(name, value, units string) *types.IRODSMeta {
+		return &types.IRODSMeta{Name: name, Value: value, Units: units}
+	}
+
+	metas := []*types.IRODSMeta{
+		meta(ppath.ModTimeAttribute, "1700000000", ""),
+		meta(ppath.ModeAttribute, "644", ""),
+		meta("project", "3010000.01", ""),
+		meta("subject", "sub-01", ""),
+		meta("size", "4", "bytes"),
+	}
+
+	cases := []struct {
+		name     string
+		existing []*types.IRODSMeta
+		expected []string
+	}{
+		{"new replacement", nil, []string{"project", "subject", "size"}},
+		{
+			"copied by a previous attempt",
+			[]*types.IRODSMeta{meta("project", "3010000.01", ""), meta(ppath.ModTimeAttribute, "1700000001", "")},
+			[]string{"subject", "size"},
+		},
+		{
+			"different value or units",
+			[]*types.IRODSMeta{meta("subject", "sub-02", ""), meta("size", "4", "")},
+			[]string{"project", "subject", "size"},
+		},
+	}
+
+	for _, c := range cases {
+		var got []string
+		for _, m := range carriedMetadata(metas, c.existing) {
+			got = append(got, m.Name)
+		}
+		if strings.Join(got, ",") != strings.Join(c.expected, ",") {
+			t.Errorf("%s: expected %v, got %v", c.name, c.expected, got)
+		}
+	}
+}
@@ -0,0 +1,669 @@
+package main
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cyverse/go-irodsclient/fs"
+	"github.com/cyverse/go-irodsclient/irods/common"
+	"github.com/dccn-tg/dr-data-stager/pkg/dr"
+	"github.com/dccn-tg/dr-data-stager/pkg/event"
+	ppath "github.com/dccn-tg/dr-data-stager/pkg/path"
+	"github.com/dccn-tg/dr-data-stager/pkg/tasks"
+	log "github.com/dccn-tg/tg-toolset-golang/pkg/logger"
+)
+
+// Names of the bundle archives and their indices in the destination collection, e.g.
+// `.stager-bundle-<id>.tar` and `.stager-bundle-<id>.index.json`.
+const (
+	bundlePrefix      = ".stager-bundle-"
+	bundleSuffix      = ".tar"
+	bundleIndexSuffix = ".index.json"
+)
+
+// bundleID matches the identifier in the name of a bundle archive, see `bundleName`.
+var bundleID = regexp.MustCompile(`^[0-9a-f]{16}$`)
+
+// bundleName returns the name of the bundle archive of the `files`.  The name is derived
+// from the names of the files, so that a bundle keeps its name as long as it has the same
+// files, regardless of the files added to or removed from the other bundles.
+func bundleName(files []ppath.PathInfo) string {
+	h := sha256.New()
+	for _, f := range files {
+		fmt.Fprintf(h, "%s\n", filepath.Base(f.Path))
+	}
+	return fmt.Sprintf("%s%x%s", bundlePrefix, h.Sum(nil)[:8], bundleSuffix)
+}
+
+// isBundle checks whether the path refers to a bundle archive.  An archive renamed by the
+// conflict policy is not a bundle.
+func isBundle(p string) bool {
+	name := path.Base(p)
+	return strings.HasPrefix(name, bundlePrefix) && strings.HasSuffix(name, bundleSuffix) &&
+		bundleID.MatchString(strings.TrimSuffix(strings.TrimPrefix(name, bundlePrefix), bundleSuffix))
+}
+
+// isBundleIndex checks whether the path refers to the index of a bundle archive.
+func isBundleIndex(p string) bool {
+	name := path.Base(p)
+	return strings.HasPrefix(name, bundlePrefix) && strings.HasSuffix(name, bundleIndexSuffix) &&
+		bundleID.MatchString(strings.TrimSuffix(strings.TrimPrefix(name, bundlePrefix), bundleIndexSuffix))
+}
+
+// bundleIndex returns the path of the index of the bundle `archive`.
+func bundleIndex(archive string) string {
+	return strings.TrimSuffix(archive, bundleSuffix) + bundleIndexSuffix
+}
+
+// bundleEntry is the record of a file in the index of a bundle archive.
+type bundleEntry struct {
+	Name     string `json:"name"`
+	Size     int64  `json:"size"`
+	ModTime  int64  `json:"mtime"`
+	Mode     string `json:"mode"`
+	Checksum string `json:"checksum,omitempty"`
+}
+
+// sameAs checks whether the local file `f` of the entry is identical to the file of the entry
+// `o` in the index of a bundle, according to the `compare` strategy.
+func (e bundleEntry) sameAs(o bundleEntry, f ppath.PathInfo) bool {
+	if e.Name != o.Name || e.Size != o.Size {
+		return false
+	}
+
+	switch compare {
+	case ppath.CompareAlways:
+		return false
+	case ppath.CompareSizeModTime:
+		return e.ModTime == o.ModTime && e.Mode == o.Mode
+	default:
+		c, err := f.ChecksumOf(ppath.ChecksumSHA256)
+		if err != nil {
+			log.Errorf("%s\n", err)
+			return false
+		}
+		return c.String() == o.Checksum
+	}
+}
+
+// sameAsLocal checks whether the local file `f` is identical to the file of the entry in the
+// index of a bundle, according to the `compare` strategy.  The mode is not compared, as the
+// unpacked files get the permission mode `fileMode`.
+func (e bundleEntry) sameAsLocal(f ppath.PathInfo) bool {
+	if !f.Mode.IsRegular() || f.Size != e.Size {
+		return false
+	}
+
+	switch compare {
+	case ppath.CompareAlways:
+		return false
+	case ppath.CompareSizeModTime:
+		return f.ModTime.Unix() == e.ModTime
+	default:
+		c, err := f.ChecksumOf(ppath.ChecksumSHA256)
+		if err != nil {
+			log.Errorf("%s\n", err)
+			return false
+		}
+		return c.String() == e.Checksum
+	}
+}
+
+// bundler sets aside the small files of a local source directory, and uploads them in tar
+// archives after the scan of the source is complete.  The small files of a directory are
+// bundled in the order of their names, into one or more archives of at most `bundleSize`
+// bytes in the corresponding destination collection.
+type bundler struct {
+	srcbase string
+	dstbase string
+	// small files grouped by the relative path of their directory
+	groups map[string][]ppath.PathInfo
+	// names of all files grouped by the relative path of their directory
+	names map[string]map[string]struct{}
+}
+
+// newBundler returns a bundler for the transfer from `src` to `dst`, or nil if the small
+// files are not to be bundled.  Bundling applies to the upload of a local directory.
+func newBundler(src, dst ppath.PathInfo) *bundler {
+	if bundleThreshold <= 0 || src.Type != ppath.TypeFileSystem || dst.Type != ppath.TypeIrods || !src.Mode.IsDir() {
+		return nil
+	}
+	return &bundler{
+		srcbase: src.Path,
+		dstbase: dst.Path,
+		groups:  make(map[string][]ppath.PathInfo),
+		names:   make(map[string]map[string]struct{}),
+	}
+}
+
+// Add sets aside the file `f` if it is smaller than the `bundleThreshold`.  It returns
+// `false` if the file is to be synced on its own.
+func (b *bundler) Add(f ppath.PathInfo) bool {
+	if b == nil {
+		return false
+	}
+
+	dir := path.Dir(strings.TrimPrefix(strings.TrimPrefix(f.Path, b.srcbase), "/"))
+	if b.names[dir] == nil {
+		b.names[dir] = make(map[string]struct{})
+	}
+	b.names[dir][filepath.Base(f.Path)] = struct{}{}
+
+	if !f.Mode.IsRegular() || f.Size >= bundleThreshold {
+		return false
+	}
+
+	b.groups[dir] = append(b.groups[dir], f)
+	return true
+}
+
+// Sync uploads the files set aside in bundles, and sends the output of every file to the
+// `processed` channel.  Once all bundles of a directory are uploaded, the bundles superseded
+// by them are removed.
+func (b *bundler) Sync(ctx context.Context, processed chan syncOutput, counter *byteCounter) {
+	if b == nil {
+		return
+	}
+
+	dirs := make([]string, 0, len(b.groups))
+	for dir := range b.groups {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	for _, dir := range dirs {
+		var (
+			archives = make(map[string]struct{})
+			failed   bool
+		)
+		for _, bundle := range splitBundles(b.groups[dir]) {
+			archive := path.Join(b.dstbase, dir, bundleName(bundle))
+			archives[archive] = struct{}{}
+			for _, out := range b.syncBundle(ctx, archive, bundle, counter) {
+				failed = failed || out.Error != nil
+				select {
+				case processed <- out:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+
+		if !dryRun && !failed {
+			removeSuperseded(session.Context(ctx), path.Join(b.dstbase, dir), archives, b.names[dir])
+		}
+	}
+}
+
+// splitBundles splits the `files` of a directory, in the order of their names, into bundles
+// of at most `bundleSize` bytes.  A bundle has at least one file.
+func splitBundles(files []ppath.PathInfo) [][]ppath.PathInfo {
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+
+	var (
+		bundles [][]ppath.PathInfo
+		bundle  []ppath.PathInfo
+		size    int64
+	)
+	for i, f := range files {
+		bundle = append(bundle, f)
+		size += f.Size
+		if i < len(files)-1 && size+files[i+1].Size <= bundleSize {
+			continue
+		}
+		bundles = append(bundles, bundle)
+		bundle, size = nil, 0
+	}
+	return bundles
+}
+
+// removeSuperseded removes the bundles in the collection `coll` other than the `current`
+// ones, of which all files are still in the source directory with the file `names`.  The
+// files of these bundles are either in the `current` bundles or synced on their own, the
+// bundles are not to be unpacked over the newer data.  A bundle with a file no longer in the
+// source is kept, unless it is removed as an extraneous file in the mirror mode.
+func removeSuperseded(ctx context.Context, coll string, current, names map[string]struct{}) {
+
+	ifs := ctx.Value(dr.KeyFilesystem).(*fs.FileSystem)
+
+	entries, err := ifs.List(coll)
+	if err != nil {
+		log.Warnf("cannot list bundles in %s: %s\n", coll, err)
+		return
+	}
+
+	for _, e := range entries {
+		if _, ok := current[e.Path]; ok || e.Type != fs.FileEntry || !isBundle(e.Path) {
+			continue
+		}
+
+		old, err := readBundleIndex(ctx, e.Path)
+		if err != nil {
+			log.Warnf("keep bundle %s: %s\n", e.Path, err)
+			continue
+		}
+
+		if !isSuperseded(old, names) {
+			log.Debugf("keep bundle %s with files not in the source\n", e.Path)
+			continue
+		}
+
+		log.Debugf("remove superseded bundle: %s\n", e.Path)
+		for _, p := range []string{e.Path, bundleIndex(e.Path)} {
+			if err := ifs.RemoveFile(p, true); err != nil {
+				log.Warnf("cannot remove superseded bundle %s: %s\n", p, err)
+			}
+		}
+	}
+}
+
+// isSuperseded checks whether the bundle with the index `entries` is superseded, i.e. all its
+// files are still in the source directory with the file `names`.
+func isSuperseded(entries []bundleEntry, names map[string]struct{}) bool {
+	for _, e := range entries {
+		if _, ok := names[e.Name]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// sameIndex checks whether the `entries` of the local `files` are identical to the index
+// `old` of the bundle at the destination, according to the `compare` strategy.
+func sameIndex(entries, old []bundleEntry, files []ppath.PathInfo) bool {
+	if len(old) != len(entries) {
+		return false
+	}
+	for i := range entries {
+		if !entries[i].sameAs(old[i], files[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// syncBundle uploads the local `files` in the bundle `archive`, and returns the output of
+// every file.  The bundle is skipped if its index at the destination has the same files;
+// otherwise an existing bundle is handled by the `conflicts` policy.
+func (b *bundler) syncBundle(ctx context.Context, archive string, files []ppath.PathInfo, counter *byteCounter) []syncOutput {
+
+	// the filesystem of the session may have been reconnected by the retry of another
+	// file transfer.
+	ctx = session.Context(ctx)
+
+	var size int64
+	entries := make([]bundleEntry, len(files))
+	for i, f := range files {
+		entries[i] = bundleEntry{
+			Name:    filepath.Base(f.Path),
+			Size:    f.Size,
+			ModTime: f.ModTime.Unix(),
+			Mode:    fmt.Sprintf("%04o", f.Mode.Perm()),
+		}
+		size += f.Size
+	}
+
+	// the bundle is identical if all files in the index are identical
+	cur, err := ctx.Value(dr.KeyFilesystem).(*fs.FileSystem).Stat(archive)
+	exists := err == nil && cur.Type == fs.FileEntry
+	old, err := readBundleIndex(ctx, archive)
+	same := exists && err == nil && sameIndex(entries, old, files)
+
+	outs := make([]syncOutput, len(files))
+	for i, f := range files {
+		outs[i] = syncOutput{
+			File:    f.Path,
+			DstFile: archive,
+			Size:    f.Size,
+			ModTime: f.ModTime,
+			Bundled: true,
+		}
+	}
+
+	switch {
+	case same:
+		log.Debugf("skip bundle: %s\n", archive)
+		for i := range outs {
+			outs[i].Checksum = old[i].Checksum
+			outs[i].Skipped = true
+		}
+		counter.Add(size)
+		return outs
+	case exists:
+		// the existing bundle differs, the policy applies to all files of the bundle
+		out := syncOutput{File: path.Dir(files[0].Path), DstFile: archive, Size: size}
+		done := resolveConflict(ctx, &out, ppath.PathInfo{Path: archive, Type: ppath.TypeIrods}, counter)
+		for i := range outs {
+			outs[i].Conflict = out.Conflict
+			outs[i].Renamed = out.Renamed
+			outs[i].Skipped = out.Skipped
+			outs[i].Error = out.Error
+		}
+		if done {
+			return outs
+		}
+	}
+
+	if dryRun {
+		for i := range outs {
+			outs[i] = plan(outs[i], exists, counter)
+		}
+		return outs
+	}
+
+	// the existing bundle is replaced, unless it is renamed aside by the conflict policy
+	var replaced int64
+	if exists && outs[0].Renamed == "" {
+		replaced = cur.Size
+	}
+	if err := space.Reserve(size, replaced); err != nil {
+		for i := range outs {
+			outs[i].Error = err
+		}
+		counter.Add(size)
+		return outs
+	}
+
+	log.Debugf("irods bundle: %d files -> %s\n", len(files), archive)
+	events.Emit(event.Event{Type: event.TypeFileStarted, File: path.Dir(files[0].Path), DstFile: archive, Size: size})
+
+	tracker := newFileTracker(counter)
+	err = withRetry(ctx, archive, func(ctx context.Context) error {
+		tracker.Reset()
+		if err := writeBundle(ctx, archive, files, entries, tracker.Update); err != nil {
+			return err
+		}
+		return writeBundleIndex(ctx, archive, entries)
+	})
+	tracker.Done(size)
+
+	// continue with the filesystem reconnected by the retries, if any.
+	ctx = session.Context(ctx)
+
+	if err == nil {
+		if _, err := irodsChecksum(ctx, archive); err != nil {
+			log.Warnf("cannot register checksum of %s: %s\n", archive, err)
+		}
+
+		fields := tasks.MetadataFields{
+			Path:    strings.TrimPrefix(strings.TrimPrefix(archive, b.dstbase), "/"),
+			Name:    path.Base(archive),
+			Size:    size,
+			ModTime: files[len(files)-1].ModTime,
+		}
+		err = applyMetadata(ctx, archive, fields)
+	}
+
+	for i := range outs {
+		outs[i].Checksum = entries[i].Checksum
+		outs[i].Error = err
+	}
+	return outs
+}
+
+// writeBundle uploads the local `files` as the tar `archive`.  The checksums of the files
+// are computed while the files are written to the archive, and set to the `entries`.
+func writeBundle(ctx context.Context, archive string, files []ppath.PathInfo, entries []bundleEntry, callback common.TrackerCallBack) error {
+
+	pr, pw := io.Pipe()
+
+	done := make(chan error, 1)
+	go func() {
+		err := writeTar(pw, files, entries, callback)
+		pw.CloseWithError(err)
+		done <- err
+	}()
+
+	tmp := partialPath(archive)
+	partials.Add(tmp, ppath.TypeIrods)
+
+	err := putStream(ctx, pr, tmp, -1, nil)
+
+	// unblock the tar writer if the upload is interrupted
+	pr.CloseWithError(fmt.Errorf("upload of %s interrupted", archive))
+
+	terr := <-done
+	if err == nil && terr != nil {
+		err = fmt.Errorf("cannot bundle files into %s: %w", archive, terr)
+	}
+	if err != nil {
+		if rerr := ctx.Value(dr.KeyFilesystem).(*fs.FileSystem).RemoveFile(tmp, true); rerr != nil {
+			log.Debugf("cannot remove %s: %s\n", tmp, rerr)
+		}
+		return err
+	}
+	return replaceObject(ctx, tmp, archive)
+}
+
+// writeTar writes the local `files` with the names in `entries` to the tar stream `w`, and
+// reports the accumulated size of the written files to the `callback`.
+func writeTar(w io.Writer, files []ppath.PathInfo, entries []bundleEntry, callback common.TrackerCallBack) error {
+
+	var size, done int64
+	for _, f := range files {
+		size += f.Size
+	}
+
+	tw := tar.NewWriter(w)
+
+	for i, f := range files {
+		fh, err := os.Open(f.Path)
+		if err != nil {
+			return err
+		}
+
+		hdr := &tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     entries[i].Name,
+			Mode:     int64(f.Mode.Perm()),
+			Size:     f.Size,
+			ModTime:  f.ModTime,
+			Format:   tar.FormatPAX,
+		}
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			fh.Close()
+			return err
+		}
+
+		h := sha256.New()
+		_, err = io.Copy(tw, io.TeeReader(fh, h))
+		fh.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.Path, err)
+		}
+
+		entries[i].Checksum = ppath.Checksum{Algorithm: ppath.ChecksumSHA256, Value: h.Sum(nil)}.String()
+
+		done += f.Size
+		if callback != nil {
+			callback(done, size)
+		}
+	}
+
+	return tw.Close()
+}
+
+// readBundleIndex reads the index of the bundle `archive` in iRODS.
+func readBundleIndex(ctx context.Context, archive string) ([]bundleEntry, error) {
+
+	fh, err := ctx.Value(dr.KeyFilesystem).(*fs.FileSystem).OpenFile(bundleIndex(archive), "", "r")
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+
+	data, err := io.ReadAll(fh)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []bundleEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("invalid index of %s: %w", archive, err)
+	}
+	return entries, nil
+}
+
+// writeBundleIndex writes the `entries` as the index of the bundle `archive` in iRODS.
+func writeBundleIndex(ctx context.Context, archive string, entries []bundleEntry) error {
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+
+	fh, err := ctx.Value(dr.KeyFilesystem).(*fs.FileSystem).CreateFile(bundleIndex(archive), "", "w")
+	if err != nil {
+		return fmt.Errorf("cannot create index of %s: %w", archive, err)
+	}
+
+	if _, err := fh.Write(data); err != nil {
+		fh.Close()
+		return fmt.Errorf("cannot write index of %s: %w", archive, err)
+	}
+	return fh.Close()
+}
+
+// unpackBundle extracts the files of the bundle `archive` of `size` bytes in iRODS into the
+// local directory `dir`.  The extracted files get the modification time of the original
+// files and the permission mode `fileMode`.
+//
+// A local file identical to the file in the index of the bundle is not extracted again;
+// a local file that differs is handled by the `conflicts` policy.  It returns the paths of
+// the local files of the bundle, including the existing files kept by the policy, and
+// whether the policy is applied to any of the files.
+func unpackBundle(ctx context.Context, archive, dir string, size int64, callback common.TrackerCallBack) ([]string, bool, error) {
+
+	if err := streams.Acquire(ctx, 1); err != nil {
+		return nil, false, err
+	}
+	defer streams.Release(1)
+
+	// without an index, every existing local file differs
+	index := make(map[string]bundleEntry)
+	if entries, err := readBundleIndex(ctx, archive); err == nil {
+		for _, e := range entries {
+			index[e.Name] = e
+		}
+	}
+
+	fh, err := ctx.Value(dr.KeyFilesystem).(*fs.FileSystem).OpenFile(archive, "", "r")
+	if err != nil {
+		return nil, false, err
+	}
+	defer fh.Close()
+
+	var (
+		unpacked []string
+		conflict bool
+		failed   error
+		done     int64
+	)
+
+	tr := tar.NewReader(bandwidth.Reader(ctx, fh))
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return unpacked, conflict, failed
+		}
+		if err != nil {
+			return unpacked, conflict, fmt.Errorf("cannot read %s: %w", archive, err)
+		}
+
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		// files in a bundle are in the same directory
+		if hdr.Name != filepath.Base(hdr.Name) || hdr.Name == ".." {
+			return unpacked, conflict, fmt.Errorf("invalid file in %s: %s", archive, hdr.Name)
+		}
+
+		p := filepath.Join(dir, hdr.Name)
+
+		extract := true
+		if cur, err := ppath.GetPathInfo(ctx, p); err == nil {
+			e, ok := index[hdr.Name]
+			switch {
+			case ok && e.sameAsLocal(cur):
+				log.Debugf("skip unpack: %s == %s\n", p, hdr.Name)
+				extract = false
+			case conflicts == ppath.ConflictSkip:
+				log.Debugf("skip unpack: %s exists\n", p)
+				conflict, extract = true, false
+			case conflicts == ppath.ConflictFail:
+				if failed == nil {
+					failed = fmt.Errorf("destination exists: %s", p)
+				}
+				conflict, extract = true, false
+			case conflicts == ppath.ConflictRename:
+				renamed := ppath.RenamedPath(p, time.Now())
+				log.Debugf("rename: %s -> %s\n", p, renamed)
+				if err := renameFile(ctx, cur, renamed); err != nil {
+					return unpacked, true, err
+				}
+				unpacked = append(unpacked, renamed)
+				conflict = true
+			default:
+				conflict = true
+			}
+		}
+
+		if extract {
+			if err := extractFile(p, tr, hdr); err != nil {
+				return unpacked, conflict, fmt.Errorf("cannot extract %s from %s: %w", hdr.Name, archive, err)
+			}
+		}
+		unpacked = append(unpacked, p)
+
+		done += hdr.Size
+		if callback != nil {
+			callback(done, size)
+		}
+	}
+}
+
+// extractFile writes the file of the tar header `hdr` read from `r` to the local path `p`.
+func extractFile(p string, r io.Reader, hdr *tar.Header) error {
+
+	part := partialPath(p)
+	partials.Add(part, ppath.TypeFileSystem)
+
+	f, err := os.OpenFile(part, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, fileMode)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		os.Remove(part)
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		os.Remove(part)
+		return err
+	}
+
+	if err := os.Chmod(part, fileMode); err != nil {
+		return err
+	}
+
+	if err := os.Chtimes(part, hdr.ModTime, hdr.ModTime); err != nil {
+		return err
+	}
+
+	return os.Rename(part, p)
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	ppath "github.com/dccn-tg/dr-data-stager/pkg/path"
+)
+
+// smallFiles returns the local files with the `names` in the directory `dir`, each of `size`
+// bytes.
+func smallFiles(dir string, size int64, names ...string) []ppath.PathInfo {
+	files := make([]ppath.PathInfo, len(names))
+	for i, n := range names {
+		files[i] = ppath.PathInfo{Path: filepath.Join(dir, n), Type: ppath.TypeFileSystem, Mode: 0644, Size: size}
+	}
+	return files
+}
+
+// bundleNames returns the names of the bundles into which the `files` are split.
+func bundleNames(files []ppath.PathInfo) []string {
+	var names []string
+	for _, b := range splitBundles(files) {
+		names = append(names, bundleName(b))
+	}
+	return names
+}
+
+func TestBundleName(t *testing.T) {
+
+	defer func(s int64) { bundleSize = s }(bundleSize)
+	bundleSize = 300
+
+	// three bundles of three files each
+	names := []string{"a", "b", "c", "d", "e", "f", "g", "h", "i"}
+	orig := bundleNames(smallFiles("/data", 100, names...))
+	if len(orig) != 3 {
+		t.Fatalf("expected 3 bundles, got %d", len(orig))
+	}
+	for _, n := range orig {
+		if !isBundle(n) || !isBundleIndex(bundleIndex(n)) {
+			t.Errorf("%s: not a bundle name", n)
+		}
+	}
+
+	cases := []struct {
+		name  string
+		files []string
+		// bundles expected to keep their names
+		kept []int
+	}{
+		{"same files", names, []int{0, 1, 2}},
+		{"file added to the last bundle", append(names[:9:9], "ga"), []int{0, 1}},
+		{"file added in a new bundle", append(names[:9:9], "j", "k", "l"), []int{0, 1, 2}},
+		{"file removed from the last bundle", names[:8], []int{0, 1}},
+		{"file removed from the first bundle", names[1:], nil},
+	}
+
+	for _, c := range cases {
+		got := strings.Join(bundleNames(smallFiles("/data", 100, c.files...)), ",")
+		for i, n := range orig {
+			kept := strings.Contains(got, n)
+			expected := false
+			for _, k := range c.kept {
+				expected = expected || k == i
+			}
+			if kept != expected {
+				t.Errorf("%s: bundle %d kept name: %t, expected %t", c.name, i, kept, expected)
+			}
+		}
+	}
+
+	// the name depends on the names of the files only.
+	if bundleName(smallFiles("/data", 100, "a", "b")) != bundleName(smallFiles("/other", 200, "a", "b")) {
+		t.Errorf("bundle name depends on the directory or size of the files")
+	}
+}
+
+func TestIsSuperseded(t *testing.T) {
+
+	names := map[string]struct{}{"a": {}, "b": {}, "c": {}}
+
+	cases := []struct {
+		name     string
+		entries  []string
+		expected bool
+	}{
+		{"all files in the source", []string{"a", "b"}, true},
+		{"file no longer in the source", []string{"a", "x"}, false},
+		{"empty index", nil, true},
+	}
+
+	for _, c := range cases {
+		var entries []bundleEntry
+		for _, n := range c.entries {
+			entries = append(entries, bundleEntry{Name: n})
+		}
+		if got := isSuperseded(entries, names); got != c.expected {
+			t.Errorf("%s: expected %t, got %t", c.name, c.expected, got)
+		}
+	}
+}
+
+func TestSameIndex(t *testing.T) {
+
+	defer func(c ppath.CompareStrategy) { compare = c }(compare)
+
+	dir := t.TempDir()
+	mtime := time.Unix(1700000000, 0)
+
+	var files []ppath.PathInfo
+	for _, n := range []string{"a.txt", "b.txt"} {
+		p := filepath.Join(dir, n)
+		if err := os.WriteFile(p, []byte(n), 0644); err != nil {
+			t.Fatalf("%s\n", err)
+		}
+		files = append(files, ppath.PathInfo{Path: p, Type: ppath.TypeFileSystem, Mode: 0644, Size: 5, ModTime: mtime})
+	}
+
+	entries := make([]bundleEntry, len(files))
+	for i, f := range files {
+		c, err := f.ChecksumOf(ppath.ChecksumSHA256)
+		if err != nil {
+			t.Fatalf("%s\n", err)
+		}
+		entries[i] = bundleEntry{Name: filepath.Base(f.Path), Size: f.Size, ModTime: mtime.Unix(), Mode: "0644", Checksum: c.String()}
+	}
+
+	// index returns a copy of the entries with the entry `i` modified by `f`.
+	index := func(i int, f func(*bundleEntry)) []bundleEntry {
+		old := append([]bundleEntry{}, entries...)
+		if i >= 0 {
+			f(&old[i])
+		}
+		return old
+	}
+
+	cases := []struct {
+		name string
+		old  []bundleEntry
+		// expected result for the checksum, size-mtime and always strategies
+		expected [3]bool
+	}{
+		{"identical", index(-1, nil), [3]bool{true, true, false}},
+		{"missing file", entries[:1], [3]bool{false, false, false}},
+		{"different name", index(1, func(e *bundleEntry) { e.Name = "c.txt" }), [3]bool{false, false, false}},
+		{"different size", index(1, func(e *bundleEntry) { e.Size = 6 }), [3]bool{false, false, false}},
+		{"different mtime", index(0, func(e *bundleEntry) { e.ModTime++ }), [3]bool{true, false, false}},
+		{"different mode", index(0, func(e *bundleEntry) { e.Mode = "0600" }), [3]bool{true, false, false}},
+		{"different checksum", index(0, func(e *bundleEntry) { e.Checksum = entries[1].Checksum }), [3]bool{false, true, false}},
+	}
+
+	strategies := []ppath.CompareStrategy{ppath.CompareChecksum, ppath.CompareSizeModTime, ppath.CompareAlways}
+
+	for _, c := range cases {
+		for i, s := range strategies {
+			compare = s
+			if got := sameIndex(entries, c.old, files); got != c.expected[i] {
+				t.Errorf("%s, %s: expected %t, got %t", c.name, s, c.expected[i], got)
+			}
+		}
+	}
+
+	// unpacked files are compared without the mode
+	for i, s := range strategies {
+		compare = s
+		f := files[0]
+		f.Mode = 0600
+		if got, expected := entries[0].sameAsLocal(f), i < 2; got != expected {
+			t.Errorf("local, %s: expected %t, got %t", s, expected, got)
+		}
+		f.ModTime = mtime.Add(time.Second)
+		if got, expected := entries[0].sameAsLocal(f), i == 0; got != expected {
+			t.Errorf("local mtime, %s: expected %t, got %t", s, expected, got)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/cyverse/go-irodsclient/fs"
+	"github.com/dccn-tg/dr-data-stager/pkg/dr"
+	ppath "github.com/dccn-tg/dr-data-stager/pkg/path"
+	log "github.com/dccn-tg/tg-toolset-golang/pkg/logger"
+	"golang.org/x/sys/unix"
+)
+
+// insufficientSpaceError is the error of the destination not having enough space for the data
+// to be transferred.
+type insufficientSpaceError struct {
+	dst       string
+	required  int64
+	available int64
+}
+
+func (e insufficientSpaceError) Error() string {
+	return fmt.Sprintf("at least %d bytes to transfer, only %d bytes available at %s", e.required, e.available, e.dst)
+}
+
+// isInsufficientSpace checks whether `err` is an `insufficientSpaceError`.
+func isInsufficientSpace(err error) bool {
+	var e insufficientSpaceError
+	return errors.As(err, &e)
+}
+
+// spaceBudget keeps track of the space at the destination taken by the files of the task,
+// against the space available at the start of the task.  The files are counted as they are
+// found by the scanner and compared with the destination, so that the task is aborted as soon
+// as the destination cannot hold the data, without walking the source in advance.
+//
+// A `nil` spaceBudget is valid and never runs out of space.
+type spaceBudget struct {
+	dst       string
+	available int64
+	required  atomic.Int64
+}
+
+// space is the space budget of the destination of the task.
+var space *spaceBudget
+
+// newSpaceBudget returns the budget of the space available at the destination `dst`, or `nil`
+// if the available space cannot be determined.
+func newSpaceBudget(ctx context.Context, dst ppath.PathInfo) *spaceBudget {
+	avail, ok := availableSpace(ctx, dst)
+	if !ok {
+		log.Debugf("[%s] available space of %s unknown, skip capacity check", taskID, dst.Path)
+		return nil
+	}
+	log.Debugf("[%s] %d bytes available at %s", taskID, avail, dst.Path)
+	return &spaceBudget{dst: dst.Path, available: avail}
+}
+
+// Reserve takes the space of a file of `size` bytes replacing the file of `replaced` bytes at
+// the destination.  It returns an `insufficientSpaceError` if the files reserved so far don't
+// fit in the available space, the file is then not to be transferred.
+func (b *spaceBudget) Reserve(size, replaced int64) error {
+	if b == nil || size <= replaced {
+		return nil
+	}
+	if required := b.required.Add(size - replaced); required > b.available {
+		return insufficientSpaceError{dst: b.dst, required: required, available: b.available}
+	}
+	return nil
+}
+
+// replacedSize returns the size of the existing destination file `dst` replaced by the file
+// `out`, or 0 if the destination file doesn't exist, i.e. `err` is not nil, or it is renamed
+// aside by the conflict policy.
+func replacedSize(out syncOutput, dst ppath.PathInfo, err error) int64 {
+	if err != nil || out.Renamed != "" || !dst.Mode.IsRegular() {
+		return 0
+	}
+	return dst.Size
+}
+
+// availableSpace returns the space in bytes available at the destination `dst`.  For a local
+// destination, it is the free space of the filesystem available to the user; for an iRODS
+// destination, it is the quota of the DR collection minus the size of its data.  The bool
+// value is false if the available space cannot be determined.
+func availableSpace(ctx context.Context, dst ppath.PathInfo) (int64, bool) {
+	switch dst.Type {
+	case ppath.TypeIrods:
+		return irodsAvailableSpace(ctx, dst.Path)
+	default:
+		return localAvailableSpace(dst.Path)
+	}
+}
+
+// localAvailableSpace returns the free space of the filesystem on which the local path `p`,
+// or its nearest existing parent directory, is located.
+func localAvailableSpace(p string) (int64, bool) {
+	for {
+		var st unix.Statfs_t
+		err := unix.Statfs(p, &st)
+		if err == nil {
+			return int64(st.Bavail) * int64(st.Bsize), true
+		}
+
+		parent := filepath.Dir(p)
+		if !os.IsNotExist(err) || parent == p {
+			log.Warnf("[%s] cannot get free space of %s: %s", taskID, p, err)
+			return 0, false
+		}
+		p = parent
+	}
+}
+
+// irodsAvailableSpace returns the available quota of the DR collection in which the iRODS
+// path `p` is located.  The DR collection is the nearest parent collection with the quota
+// attribute.
+func irodsAvailableSpace(ctx context.Context, p string) (int64, bool) {
+
+	ifs := ctx.Value(dr.KeyFilesystem).(*fs.FileSystem)
+
+	for ; p != "/" && p != "."; p = path.Dir(p) {
+		if !ifs.ExistsDir(p) {
+			continue
+		}
+
+		metas, err := ifs.ListMetadata(p)
+		if err != nil {
+			log.Warnf("[%s] cannot get metadata of %s: %s", taskID, p, err)
+			return 0, false
+		}
+
+		avail, ok, err := dr.AvailableQuota(metas)
+		if err != nil {
+			log.Warnf("[%s] %s of %s", taskID, err, p)
+			return 0, false
+		}
+		if ok {
+			return avail, true
+		}
+	}
+
+	return 0, false
+}
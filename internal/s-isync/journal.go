@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	ppath "github.com/dccn-tg/dr-data-stager/pkg/path"
+	log "github.com/dccn-tg/tg-toolset-golang/pkg/logger"
+)
+
+// journalEntry is the checkpoint record of a file that has been synced.
+type journalEntry struct {
+	Path     string `json:"path"`
+	DstPath  string `json:"dstPath"`
+	Size     int64  `json:"size"`
+	ModTime  int64  `json:"mtime"`
+	Checksum string `json:"checksum,omitempty"`
+}
+
+// journal is the checkpoint journal of the files synced by the task.  It is kept in
+// the state directory of the task so that a retry of the task can skip the files
+// that are already synced by the previous attempts, without comparing the checksums.
+//
+// A `nil` journal is valid; it has no checkpoint and discards all records.
+type journal struct {
+	mu   sync.Mutex
+	f    *os.File
+	enc  *json.Encoder
+	done map[string]journalEntry
+}
+
+// openJournal loads the checkpoint journal from the state directory `dir`, and opens
+// it for appending new records.  It returns a `nil` journal when `dir` is an empty string.
+func openJournal(dir string) (*journal, error) {
+	if dir == "" {
+		return nil, nil
+	}
+
+	fpath := filepath.Join(dir, "journal")
+
+	f, err := os.OpenFile(fpath, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	j := &journal{
+		f:    f,
+		enc:  json.NewEncoder(f),
+		done: make(map[string]journalEntry),
+	}
+
+	// load records from previous attempts.  The last record can be incomplete if
+	// the previous attempt was killed, it is ignored and terminated below.
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e journalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			log.Warnf("[%s] skip invalid journal record: %s", taskID, err)
+			continue
+		}
+		j.done[e.Path] = e
+	}
+
+	if err := scanner.Err(); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if err := terminateLine(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	log.Debugf("[%s] %d files in checkpoint journal %s", taskID, len(j.done), fpath)
+
+	return j, nil
+}
+
+// Completed checks whether the source file `src` has been synced to `dst` by a
+// previous attempt, and the source file has not been changed since then.
+func (j *journal) Completed(src ppath.PathInfo, dst string) (journalEntry, bool) {
+	if j == nil {
+		return journalEntry{}, false
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	e, ok := j.done[src.Path]
+	if !ok {
+		return e, false
+	}
+
+	return e, e.DstPath == dst && e.Size == src.Size && e.ModTime == src.ModTime.Unix()
+}
+
+// Add appends a checkpoint record of the successfully synced file `o` to the journal.
+func (j *journal) Add(o syncOutput) error {
+	if j == nil || o.Error != nil || o.Deleted {
+		return nil
+	}
+
+	e := journalEntry{
+		Path:     o.File,
+		DstPath:  o.DstFile,
+		Size:     o.Size,
+		ModTime:  o.ModTime.Unix(),
+		Checksum: o.Checksum,
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	// the file is already in the journal, e.g. it was skipped by the journal itself.
+	if old, ok := j.done[e.Path]; ok && old == e {
+		return nil
+	}
+
+	j.done[e.Path] = e
+	return j.enc.Encode(e)
+}
+
+// Close closes the journal file.
+func (j *journal) Close() error {
+	if j == nil {
+		return nil
+	}
+	return j.f.Close()
+}
+
+// terminateLine ends an incomplete last line of the JSON-lines file `f`, left behind by an
+// attempt that was killed while writing it, so that new records are appended on lines of
+// their own.
+func terminateLine(f *os.File) error {
+	fi, err := f.Stat()
+	if err != nil || fi.Size() == 0 {
+		return err
+	}
+
+	last := make([]byte, 1)
+	if _, err := f.ReadAt(last, fi.Size()-1); err != nil {
+		return err
+	}
+	if last[0] == '\n' {
+		return nil
+	}
+
+	_, err = f.Write([]byte("\n"))
+	return err
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	ppath "github.com/dccn-tg/dr-data-stager/pkg/path"
+)
+
+func TestJournalCompleted(t *testing.T) {
+
+	mtime := time.Unix(1700000000, 0)
+	src := ppath.PathInfo{Path: "/data/a.txt", Type: ppath.TypeFileSystem, Mode: 0644, Size: 4, ModTime: mtime}
+	dst := "/nl.ru.donders/di/dccn/DAC_3010000.01/a.txt"
+
+	j, err := openJournal(t.TempDir())
+	if err != nil {
+		t.Fatalf("%s\n", err)
+	}
+	defer j.Close()
+
+	// failed transfers and deletions are not checkpoints.
+	for _, o := range []syncOutput{
+		{File: "/data/b.txt", DstFile: dst, Size: 4, ModTime: mtime, Error: errors.New("failed")},
+		{File: "/data/c.txt", DstFile: dst, Deleted: true},
+	} {
+		j.Add(o)
+		if _, ok := j.done[o.File]; ok {
+			t.Errorf("%s: unexpected checkpoint", o.File)
+		}
+	}
+
+	if err := j.Add(syncOutput{File: src.Path, DstFile: dst, Size: src.Size, ModTime: mtime, Checksum: "sha256:00"}); err != nil {
+		t.Fatalf("%s\n", err)
+	}
+
+	// modify returns a copy of the source file modified by `f`.
+	modify := func(f func(*ppath.PathInfo)) ppath.PathInfo {
+		p := src
+		f(&p)
+		return p
+	}
+
+	cases := []struct {
+		name     string
+		src      ppath.PathInfo
+		dst      string
+		expected bool
+	}{
+		{"unchanged", src, dst, true},
+		{"different destination", src, "/nl.ru.donders/di/dccn/DAC_3010000.02/a.txt", false},
+		{"different size", modify(func(p *ppath.PathInfo) { p.Size = 5 }), dst, false},
+		{"different mtime", modify(func(p *ppath.PathInfo) { p.ModTime = mtime.Add(time.Second) }), dst, false},
+		{"subsecond mtime", modify(func(p *ppath.PathInfo) { p.ModTime = mtime.Add(time.Millisecond) }), dst, true},
+		{"different mode", modify(func(p *ppath.PathInfo) { p.Mode = 0600 }), dst, true},
+		{"not in journal", modify(func(p *ppath.PathInfo) { p.Path = "/data/d.txt" }), dst, false},
+	}
+
+	for _, c := range cases {
+		if _, got := j.Completed(c.src, c.dst); got != c.expected {
+			t.Errorf("%s: expected %t, got %t", c.name, c.expected, got)
+		}
+	}
+
+	// a nil journal has no checkpoint
+	var none *journal
+	if _, ok := none.Completed(src, dst); ok {
+		t.Errorf("nil journal: unexpected checkpoint")
+	}
+}
+
+func TestJournalTruncated(t *testing.T) {
+
+	dir := t.TempDir()
+	mtime := time.Unix(1700000000, 0)
+
+	j, err := openJournal(dir)
+	if err != nil {
+		t.Fatalf("%s\n", err)
+	}
+	j.Add(syncOutput{File: "/data/a.txt", DstFile: "/coll/a.txt", Size: 4, ModTime: mtime})
+	j.Close()
+
+	// the previous attempt was killed while writing the record of the second file.
+	f, err := os.OpenFile(filepath.Join(dir, "journal"), os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatalf("%s\n", err)
+	}
+	f.WriteString(`{"path":"/data/b.txt","dstPath":"/co`)
+	f.Close()
+
+	for attempt, expected := range [][]string{
+		{"/data/a.txt"},
+		{"/data/a.txt", "/data/b.txt"},
+	} {
+		j, err := openJournal(dir)
+		if err != nil {
+			t.Fatalf("attempt %d: %s\n", attempt, err)
+		}
+
+		if len(j.done) != len(expected) {
+			t.Errorf("attempt %d: expected %d checkpoints, got %d", attempt, len(expected), len(j.done))
+		}
+		for _, p := range expected {
+			if _, ok := j.done[p]; !ok {
+				t.Errorf("attempt %d: no checkpoint of %s", attempt, p)
+			}
+		}
+
+		// the record appended after the truncated one is kept for the next attempt.
+		j.Add(syncOutput{File: "/data/b.txt", DstFile: "/coll/b.txt", Size: 4, ModTime: mtime})
+		j.Close()
+	}
+}
@@ -7,13 +7,19 @@ import (
 	"os"
 	"os/signal"
 	"os/user"
+	"path/filepath"
+	"strings"
 	"syscall"
+	"time"
 
+	"github.com/cyverse/go-irodsclient/fs"
 	"github.com/cyverse/go-irodsclient/irods/types"
 	"github.com/dccn-tg/dr-data-stager/internal/worker/config"
 	"github.com/dccn-tg/dr-data-stager/pkg/dr"
 	"github.com/dccn-tg/dr-data-stager/pkg/errors"
+	"github.com/dccn-tg/dr-data-stager/pkg/event"
 	ppath "github.com/dccn-tg/dr-data-stager/pkg/path"
+	"github.com/dccn-tg/dr-data-stager/pkg/tasks"
 	"github.com/dccn-tg/dr-data-stager/pkg/utility"
 	log "github.com/dccn-tg/tg-toolset-golang/pkg/logger"
 )
@@ -31,6 +37,25 @@ var (
 	keepPassFile      bool   = false
 	withEncryptedPass bool   = false
 	rsaKey            string = "key.pem"
+	continueOnError   bool   = false
+	verify            bool   = false
+	mirror            bool   = false
+	dryRun            bool   = false
+	parallelThreshold int64  = config.DefaultParallelThreshold
+	parallelThreads   int    = config.DefaultParallelThreads
+	maxConnections    int    = config.DefaultMaxConnections
+	bwLimit           int64  = 0
+	bwLimitStdin      bool   = false
+	bundleThreshold   int64  = 0
+	bundleSize        int64  = tasks.DefaultBundleSize
+	unpack            bool   = false
+	maxDeletions      int    = 1000
+	filter            ppath.Filter
+	userMetadata      metadataList
+	links             ppath.SymlinkPolicy   = ppath.SymlinkSkip
+	compare           ppath.CompareStrategy = ppath.CompareChecksum
+	manifestFile      string
+	stateDir          string
 	srcPath           string
 	dstPath           string
 )
@@ -38,6 +63,16 @@ var (
 func init() {
 	flag.BoolVar(&optsVerbose, "v", optsVerbose, "print debug messages")
 	flag.IntVar(&nworkers, "p", nworkers, "`number` of global concurrent workers")
+	flag.Int64Var(&parallelThreshold, "parallel-threshold", parallelThreshold, "file `size` in bytes above which a file is transferred with parallel streams")
+	flag.IntVar(&parallelThreads, "parallel-threads", parallelThreads, "`number` of parallel streams for transferring a large file, 1 to disable parallel transfer")
+	flag.IntVar(&maxConnections, "max-connections", maxConnections, "maximum `number` of iRODS connections for data transfer")
+	flag.IntVar(&retries, "retries", retries, "`number` of retries of a file transfer failed on a transient error, 0 to disable retry")
+	flag.DurationVar(&retryDelay, "retry-delay", retryDelay, "initial `delay` between the retries of a file transfer, doubled on every retry")
+	flag.Int64Var(&bwLimit, "bwlimit", bwLimit, "bandwidth `limit` of the data transfer in bytes per second, 0 for no limit")
+	flag.BoolVar(&bwLimitStdin, "bwlimit-stdin", bwLimitStdin, "read updated bandwidth limits in bytes per second, one per line, from the stdin")
+	flag.Int64Var(&bundleThreshold, "bundle-threshold", bundleThreshold, "upload files smaller than the `size` in bytes in tar bundles, 0 to disable bundling")
+	flag.Int64Var(&bundleSize, "bundle-size", bundleSize, "maximum `size` in bytes of the files in a tar bundle")
+	flag.BoolVar(&unpack, "unpack", unpack, "unpack the tar bundles when downloading from iRODS")
 	flag.StringVar(&configFile, "c", configFile, "configurateion file `path`")
 	flag.StringVar(&logFile, "l", logFile, "log file `path`")
 	flag.StringVar(&taskID, "task", taskID, "stager task `id`")
@@ -47,9 +82,38 @@ func init() {
 	flag.BoolVar(&keepPassFile, "keep-fdrpass", keepPassFile, "do not delete the file of '--fdrpass' after the credential is loaded")
 	flag.BoolVar(&withEncryptedPass, "e", withEncryptedPass, "use encrypted (R)DR data-access password")
 	flag.StringVar(&rsaKey, "k", rsaKey, "RSA key `path` for decrypting (R)DR data-access password")
+	flag.BoolVar(&continueOnError, "continue-on-error", continueOnError, "continue with remaining files when a file fails to be transferred")
+	flag.BoolVar(&verify, "verify", verify, "verify each transferred file by comparing the checksum of the source and the destination")
+	flag.BoolVar(&mirror, "mirror", mirror, "remove files at the destination that are not present at the source, after a successful transfer")
+	flag.BoolVar(&dryRun, "dry-run", dryRun, "only report the actions planned on the files, without moving data")
+	flag.IntVar(&maxDeletions, "max-deletions", maxDeletions, "maximum `number` of files allowed to be removed in the mirror mode")
+	flag.Var((*stringList)(&filter.Include), "include", "only sync files matching the glob `pattern`, can be repeated")
+	flag.Var((*stringList)(&filter.Exclude), "exclude", "skip files and directories matching the glob `pattern`, can be repeated")
+	flag.Int64Var(&filter.MinSize, "min-size", filter.MinSize, "skip files smaller than the `size` in bytes")
+	flag.Int64Var(&filter.MaxSize, "max-size", filter.MaxSize, "skip files larger than the `size` in bytes, 0 for no limit")
+	flag.Func("symlinks", "`policy` of handling symbolic links in the source directory: skip, follow or record (default skip)", func(s string) (err error) {
+		links, err = ppath.ParseSymlinkPolicy(s)
+		return
+	})
+	flag.Func("compare", "`strategy` of determining whether a file at the destination is identical to the source: checksum, size-mtime or always (default checksum)", func(s string) (err error) {
+		compare, err = ppath.ParseCompareStrategy(s)
+		return
+	})
+	flag.Func("conflict", "`policy` of handling a file existing at the destination that differs from the source: overwrite, skip, rename or fail (default overwrite)", func(s string) (err error) {
+		conflicts, err = ppath.ParseConflictPolicy(s)
+		return
+	})
+	flag.Var(&userMetadata, "metadata", "iRODS metadata attached to the uploaded data objects and the top-level collection, as a `JSON` object with attribute, value and unit; can be repeated")
+	flag.StringVar(&manifestFile, "manifest", manifestFile, "`path` of the JSON-lines file to which the transfer records of processed files are written")
+	flag.StringVar(&stateDir, "state", stateDir, "`path` of the state directory in which the checkpoint journal of the task is kept")
 
 	flag.Usage = usage
+}
 
+// parseArgs parses the command-line flags and arguments, and initializes the logger.
+// It is called from `main` rather than `init` so that the package tests run with the
+// flags of the test binary.
+func parseArgs() {
 	flag.Parse()
 
 	cfg := log.Configuration{
@@ -79,6 +143,23 @@ func init() {
 
 	srcPath = args[0]
 	dstPath = args[1]
+
+	if err := filter.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid filter: %s\n", err)
+		os.Exit(128) // invalid argument
+	}
+}
+
+// stringList implements the `flag.Value` interface for a repeatable string flag.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
 }
 
 func usage() {
@@ -91,6 +172,8 @@ func usage() {
 
 func main() {
 
+	parseArgs()
+
 	ctx, cancel := context.WithCancel(context.Background())
 
 	// load global configuration
@@ -156,7 +239,7 @@ func main() {
 
 }
 
-func run(ctx context.Context, cfg config.Configuration) *errors.IsyncError {
+func run(ctx context.Context, cfg config.Configuration) (ierr *errors.IsyncError) {
 
 	user, err := user.Current()
 	if err != nil {
@@ -165,14 +248,32 @@ func run(ctx context.Context, cfg config.Configuration) *errors.IsyncError {
 
 	log.Infof("[%s] [%s,%s] %s --> %s\n", taskID, user.Username, drUser, srcPath, dstPath)
 
-	// initialize irods filesystem
-	ifs, err := dr.NewFileSystem("stager", cfg.Dr)
+	// concurrent transfer streams, single or parallel, are bounded by the number of
+	// connections.  Allow at least one connection per sync worker.
+	if maxConnections < nworkers {
+		maxConnections = nworkers
+	}
+	streams = newStreamLimiter(maxConnections)
+
+	fileMode = cfg.Process.FilePerm()
+	comparator = ppath.NewComparator(compare)
+
+	bandwidth = newThrottle(bwLimit)
+	if bwLimitStdin {
+		go bandwidth.Follow(os.Stdin)
+	}
+
+	// initialize irods filesystem, it is reconnected by the file transfer that fails on
+	// a broken connection.
+	session, err = newIrodsSession(func() (*fs.FileSystem, error) {
+		return dr.NewFileSystemWithMaxConnections("stager", cfg.Dr, maxConnections)
+	})
 	if err != nil {
 		return errors.ToIsyncError(1, err.Error())
 	}
-	defer ifs.Release()
+	defer session.Release()
 
-	ctxfs := context.WithValue(ctx, dr.KeyFilesystem, ifs)
+	ctxfs := session.Context(ctx)
 
 	// logic of performing data transfer.
 	srcPathInfo, err := ppath.GetPathInfo(ctxfs, srcPath)
@@ -180,11 +281,19 @@ func run(ctx context.Context, cfg config.Configuration) *errors.IsyncError {
 		return errors.ToIsyncError(128, err.Error())
 	}
 
-	total := srcPathInfo.CountFiles(ctxfs)
-	nsuccess := 0
-	nfailure := 0
+	// the total of the progress grows as the source is scanned in parallel to the transfer.
+	prog := event.Progress{Provisional: true}
+	scan := newScanCounter()
+	counter := new(byteCounter)
 
-	fmt.Printf("%d,%d,%d\n", total, nsuccess, nfailure)
+	// emit the summary as the last event
+	defer func() {
+		summary := event.Event{Type: event.TypeSummary, Progress: &prog}
+		if ierr != nil {
+			summary.Error = ierr.Error()
+		}
+		events.Emit(summary)
+	}()
 
 	dstPathInfo, err := ppath.GetPathInfo(ctxfs, dstPath)
 	if err != nil && !types.IsFileNotFoundError(err) && !os.IsNotExist(err) {
@@ -195,27 +304,192 @@ func run(ctx context.Context, cfg config.Configuration) *errors.IsyncError {
 	log.Debugf("[%s] srcPathInfo: %+v", taskID, srcPathInfo)
 	log.Debugf("[%s] dstPathInfo: %+v", taskID, dstPathInfo)
 
-	processed := scanAndSync(ctxfs, cfg, srcPathInfo, dstPathInfo, nworkers)
+	manifest, err := newManifestWriter(manifestFile)
+	if err != nil {
+		return errors.ToIsyncError(128, err.Error())
+	}
+	defer manifest.Close()
+
+	// the journal is not used in the dry-run mode, as no file is synced.
+	var jnl *journal
+	if !dryRun {
+		jnl, err = openJournal(stateDir)
+		if err != nil {
+			return errors.ToIsyncError(128, err.Error())
+		}
+		defer jnl.Close()
+
+		partials, err = openPartialLog(stateDir)
+		if err != nil {
+			return errors.ToIsyncError(128, err.Error())
+		}
+		defer partials.Close()
+
+		// temporary files of previous attempts are removed, except partial downloads to
+		// be resumed by this attempt.
+		partials.Clean(ctxfs, false)
+	}
+
+	// destination paths of the source files, for determining the extraneous files
+	// at the destination in the mirror mode.
+	var synced map[string]struct{}
+	if mirror {
+		switch {
+		case !srcPathInfo.Mode.IsDir():
+			log.Warnf("[%s] mirror mode ignored for single source file: %s", taskID, srcPath)
+		case dryRun && !dstPathInfo.Mode.IsDir():
+			// nothing to be removed from a destination which is not yet created
+		default:
+			synced = make(map[string]struct{})
+		}
+	}
+
+	// heartbeats are reported until the scan is complete, as it can take long for the
+	// scanner to find files in a huge directory tree.
+	stopHeartbeat := events.Heartbeat(ctx, heartbeatInterval)
+	defer stopHeartbeat()
+
+	// the task is aborted as soon as the destination cannot hold the data, rather than
+	// failing on the first file exceeding the free space or quota.
+	if !dryRun {
+		space = newSpaceBudget(ctxfs, dstPathInfo)
+	}
+
+	events.Emit(event.Event{Type: event.TypePhase, Phase: event.PhaseScanning})
+
+	processed := scanAndSync(ctxfs, cfg, srcPathInfo, dstPathInfo, nworkers, counter, scan, jnl)
+	scanned := scan.Done()
+
+	// ticker for reporting the byte-level progress of files being transferred
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
 
 	for {
 		select {
 		case e, more := <-processed: // handle the output of a processed file
 
+			scan.Update(&prog)
+
 			if !more {
 				log.Debugf("[%s] finished", taskID)
+
+				// the filesystem may have been reconnected during the transfer.
+				ctxfs = session.Context(ctx)
+
+				// all files are processed, the remaining temporary files of previous
+				// attempts are no longer needed.
+				partials.Clean(ctxfs, true)
+
+				// the top-level collection of an uploaded directory is given the user metadata
+				// of the job, with the total size of the files as the size.
+				if !dryRun && srcPathInfo.Type == ppath.TypeFileSystem && dstPathInfo.Type == ppath.TypeIrods && srcPathInfo.Mode.IsDir() {
+					fields := fileFields(srcPathInfo, "")
+					fields.Size = prog.TotalBytes
+					if err := applyMetadata(ctxfs, dstPathInfo.Path, fields); err != nil {
+						return errors.ToIsyncError(1, err.Error())
+					}
+				}
+
+				if prog.Failure > 0 {
+					return errors.ToIsyncError(
+						errors.ExitCodePartialSuccess,
+						fmt.Sprintf("%d out of %d files failed", prog.Failure, prog.Total),
+					)
+				}
+				if synced != nil {
+					return deleteExtraneous(ctxfs, synced, manifest)
+				}
 				return nil
 			}
 
+			if synced != nil {
+				synced[filepath.Clean(e.DstFile)] = struct{}{}
+				if e.Bundled {
+					synced[filepath.Clean(bundleIndex(e.DstFile))] = struct{}{}
+				}
+				for _, p := range e.Unpacked {
+					synced[filepath.Clean(p)] = struct{}{}
+				}
+				if e.Renamed != "" {
+					synced[filepath.Clean(e.Renamed)] = struct{}{}
+				}
+			}
+
+			if dryRun && e.Skipped {
+				e.Plan = tasks.ActionSkip
+			}
+
+			if err := manifest.Write(e); err != nil {
+				log.Errorf("[%s] fail to write manifest: %s", taskID, err)
+			}
+
+			if err := jnl.Add(e); err != nil {
+				log.Errorf("[%s] fail to write checkpoint journal: %s", taskID, err)
+			}
+
+			evt := event.Event{
+				File:     e.File,
+				DstFile:  e.DstFile,
+				Size:     e.Size,
+				Conflict: string(e.Conflict),
+			}
+
 			// handle the error
 			if e.Error != nil { // something went wrong
-				nfailure++
-				fmt.Printf("%d,%d,%d\n", total, nsuccess, nfailure)
-				return errors.ToIsyncError(1, e.Error.Error())
+				prog.Failure++
+				prog.DoneBytes = counter.Load()
+
+				evt.Type = event.TypeFileError
+				evt.Error = e.Error.Error()
+				evt.Progress = &prog
+				events.Emit(evt)
+
+				if isInsufficientSpace(e.Error) {
+					return errors.ToIsyncError(errors.ExitCodeInsufficientSpace, e.Error.Error())
+				}
+
+				if !continueOnError {
+					return errors.ToIsyncError(1, e.Error.Error())
+				}
+				log.Errorf("[%s] fail to sync %s: %s", taskID, e.File, e.Error)
+				continue
 			}
 
 			// increase the counter by 1, and update the queue data
-			nsuccess++
-			fmt.Printf("%d,%d,%d\n", total, nsuccess, nfailure)
+			prog.Success++
+			prog.DoneBytes = counter.Load()
+
+			evt.Type = event.TypeFileDone
+			switch {
+			case e.LinkSkipped:
+				evt.Type = event.TypeLinkSkipped
+			case e.Plan != "":
+				evt.Type = event.TypeFilePlanned
+				evt.Action = e.Plan
+			case e.Skipped:
+				evt.Type = event.TypeFileSkipped
+			}
+			evt.Progress = &prog
+			events.Emit(evt)
+
+		case <-scanned:
+			// a nil channel is never selected again
+			scanned = nil
+			stopHeartbeat()
+
+			scan.Update(&prog)
+			log.Debugf("[%s] scan completed: %d files, %d bytes", taskID, prog.Total, prog.TotalBytes)
+			events.Emit(event.Event{Type: event.TypeProgress, Progress: &prog})
+			events.Emit(event.Event{Type: event.TypePhase, Phase: event.PhaseTransferring})
+
+		case <-ticker.C:
+			// report progress only when there are more bytes transferred, or more files found
+			total := prog.Total
+			scan.Update(&prog)
+			if b := counter.Load(); b > prog.DoneBytes || prog.Total > total {
+				prog.DoneBytes = b
+				events.Emit(event.Event{Type: event.TypeProgress, Progress: &prog})
+			}
 
 		case <-ctx.Done():
 			// receive abort signal from parent context
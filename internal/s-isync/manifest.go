@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/dccn-tg/dr-data-stager/pkg/tasks"
+)
+
+// manifestWriter writes the transfer records of processed files into a JSON-lines file.
+//
+// A `nil` manifestWriter is valid and discards all records.
+type manifestWriter struct {
+	f   *os.File
+	enc *json.Encoder
+}
+
+// newManifestWriter creates the manifest file `path`, or truncates it if it exists
+// already.  It returns a `nil` manifestWriter when `path` is an empty string.
+func newManifestWriter(path string) (*manifestWriter, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	return &manifestWriter{
+		f:   f,
+		enc: json.NewEncoder(f),
+	}, nil
+}
+
+// Write appends the transfer record of the processed file `o` to the manifest.
+func (m *manifestWriter) Write(o syncOutput) error {
+	if m == nil {
+		return nil
+	}
+
+	e := tasks.ManifestEntry{
+		Path:     o.File,
+		DstPath:  o.DstFile,
+		Size:     o.Size,
+		Checksum: o.Checksum,
+		Link:     o.Link,
+		Action:   tasks.ActionCopied,
+	}
+
+	switch {
+	case o.Error != nil:
+		e.Action = tasks.ActionFailed
+		e.Error = o.Error.Error()
+	case o.Skipped:
+		e.Action = tasks.ActionSkipped
+	case o.Deleted:
+		e.Action = tasks.ActionDeleted
+	}
+
+	// action planned in the dry-run mode
+	if o.Plan != "" && o.Error == nil {
+		e.Action = o.Plan
+	}
+
+	return m.enc.Encode(e)
+}
+
+// Close closes the manifest file.
+func (m *manifestWriter) Close() error {
+	if m == nil {
+		return nil
+	}
+	return m.f.Close()
+}
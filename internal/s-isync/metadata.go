@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	ppath "github.com/dccn-tg/dr-data-stager/pkg/path"
+	"github.com/dccn-tg/dr-data-stager/pkg/tasks"
+)
+
+// metadataList implements the `flag.Value` interface for a repeatable flag of the user
+// metadata, each given as a JSON object of `tasks.Metadata`.
+type metadataList []tasks.Metadata
+
+func (l *metadataList) String() string {
+	data, _ := json.Marshal(*l)
+	return string(data)
+}
+
+func (l *metadataList) Set(v string) error {
+	var m tasks.Metadata
+	if err := json.Unmarshal([]byte(v), &m); err != nil {
+		return fmt.Errorf("invalid metadata %s: %w", v, err)
+	}
+	if err := m.Validate(); err != nil {
+		return err
+	}
+	*l = append(*l, m)
+	return nil
+}
+
+// applyMetadata attaches the user metadata, rendered with the `fields`, to the iRODS data
+// object or collection `ipath`.
+func applyMetadata(ctx context.Context, ipath string, fields tasks.MetadataFields) error {
+	if len(userMetadata) == 0 {
+		return nil
+	}
+
+	avus := make([]tasks.Metadata, 0, len(userMetadata))
+	for _, m := range userMetadata {
+		r, err := m.Render(fields)
+		if err != nil {
+			return err
+		}
+		avus = append(avus, r)
+	}
+
+	if err := setMetadata(ctx, ipath, avus); err != nil {
+		return fmt.Errorf("cannot set metadata on %s: %w", ipath, err)
+	}
+	return nil
+}
+
+// fileFields returns the metadata fields of the local file `f` with the path `rel` relative
+// to the top-level directory.
+func fileFields(f ppath.PathInfo, rel string) tasks.MetadataFields {
+	return tasks.MetadataFields{
+		Path:    strings.TrimPrefix(rel, "/"),
+		Name:    filepath.Base(f.Path),
+		Size:    f.Size,
+		ModTime: f.ModTime,
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/cyverse/go-irodsclient/fs"
+	"github.com/dccn-tg/dr-data-stager/pkg/dr"
+	"github.com/dccn-tg/dr-data-stager/pkg/errors"
+	"github.com/dccn-tg/dr-data-stager/pkg/event"
+	ppath "github.com/dccn-tg/dr-data-stager/pkg/path"
+	"github.com/dccn-tg/dr-data-stager/pkg/tasks"
+	log "github.com/dccn-tg/tg-toolset-golang/pkg/logger"
+)
+
+// extraneousFiles scans the destination `dst` and returns the files that are not
+// in the set of `synced` destination paths.
+//
+// Files not selected by the `filter` are never extraneous, so that files excluded
+// from the transfer are kept at the destination.  Neither are the temporary files of
+// the transfers, which are skipped by the scanner and removed by the task that creates them.
+func extraneousFiles(ctx context.Context, dst ppath.PathInfo, synced map[string]struct{}) []ppath.PathInfo {
+
+	var files []ppath.PathInfo
+
+	// symbolic links at the destination are not followed, so that only the links
+	// themselves are removed rather than the files they refer to.
+	scanner := ppath.NewScanner(dst, filter, ppath.SymlinkRecord)
+	for f := range scanner.ScanMakeDir(ctx, 1000, nil) {
+		if _, ok := synced[filepath.Clean(f.Path)]; !ok {
+			files = append(files, f)
+		}
+	}
+
+	return files
+}
+
+// removeFile removes the file `f` from the local filesystem or iRODS.
+func removeFile(ctx context.Context, f ppath.PathInfo) error {
+	switch f.Type {
+	case ppath.TypeIrods:
+		return ctx.Value(dr.KeyFilesystem).(*fs.FileSystem).RemoveFile(f.Path, true)
+	default:
+		return os.Remove(f.Path)
+	}
+}
+
+// deleteExtraneous removes files at the destination that are not in the set of `synced`
+// destination paths, and reports the removed files as `TypeFileDeleted` events.
+func deleteExtraneous(ctx context.Context, synced map[string]struct{}, manifest *manifestWriter) *errors.IsyncError {
+
+	events.Emit(event.Event{Type: event.TypePhase, Phase: event.PhaseDeleting})
+
+	stopHeartbeat := events.Heartbeat(ctx, heartbeatInterval)
+	deleted, err := mirrorDst(ctx, dstPath, synced, maxDeletions)
+	stopHeartbeat()
+
+	if err != nil {
+		return errors.ToIsyncError(1, fmt.Sprintf("mirror: %s", err))
+	}
+
+	nfailure := 0
+	for o := range deleted {
+		if err := manifest.Write(o); err != nil {
+			log.Errorf("[%s] fail to write manifest: %s", taskID, err)
+		}
+
+		if o.Error != nil {
+			nfailure++
+			log.Errorf("[%s] fail to remove %s: %s", taskID, o.DstFile, o.Error)
+			events.Emit(event.Event{Type: event.TypeFileError, File: o.DstFile, Error: o.Error.Error()})
+			continue
+		}
+
+		if o.Plan != "" {
+			events.Emit(event.Event{Type: event.TypeFilePlanned, File: o.DstFile, Size: o.Size, Action: o.Plan})
+			continue
+		}
+
+		events.Emit(event.Event{Type: event.TypeFileDeleted, File: o.DstFile, Size: o.Size})
+	}
+
+	if ctx.Err() != nil {
+		return errors.ToIsyncError(130, "aborted by task")
+	}
+
+	if nfailure > 0 {
+		return errors.ToIsyncError(1, fmt.Sprintf("mirror: fail to remove %d files from destination", nfailure))
+	}
+
+	return nil
+}
+
+// mirrorDst removes files at the destination `dstPath` that are not in the set of
+// `synced` destination paths.  Nothing is removed if the number of files to be
+// removed exceeds `max`.
+//
+// The outcome of removing each file is sent to the returned channel.
+func mirrorDst(ctx context.Context, dstPath string, synced map[string]struct{}, max int) (chan syncOutput, error) {
+
+	dst, err := ppath.GetPathInfo(ctx, dstPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if !dst.Mode.IsDir() {
+		return nil, fmt.Errorf("destination is not a directory: %s", dstPath)
+	}
+
+	files := extraneousFiles(ctx, dst, synced)
+
+	log.Debugf("[%s] %d extraneous files at destination %s", taskID, len(files), dstPath)
+
+	if len(files) > max {
+		return nil, fmt.Errorf("%d files to be removed from destination exceeds the limit of %d", len(files), max)
+	}
+
+	deleted := make(chan syncOutput)
+	go func() {
+		defer close(deleted)
+		for _, f := range files {
+			o := syncOutput{
+				File:    f.Path,
+				DstFile: f.Path,
+				Size:    f.Size,
+				Deleted: true,
+			}
+			if dryRun {
+				o.Plan = tasks.ActionDelete
+			} else {
+				log.Debugf("remove: %s\n", f.Path)
+				o.Error = removeFile(ctx, f)
+			}
+			select {
+			case deleted <- o:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return deleted, nil
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+
+	"github.com/cyverse/go-irodsclient/fs"
+	"github.com/dccn-tg/dr-data-stager/pkg/dr"
+	ppath "github.com/dccn-tg/dr-data-stager/pkg/path"
+	"github.com/dccn-tg/dr-data-stager/pkg/tasks"
+)
+
+// fileMode is the permission mode of the files downloaded to the local filesystem.
+var fileMode os.FileMode = ppath.DefaultFileMode
+
+// setMetadata sets the metadata `avus` on the iRODS data object or collection `ipath`.  The
+// existing values of an attribute are replaced by the values of the attribute in `avus`;
+// they are left untouched if they are identical.
+func setMetadata(ctx context.Context, ipath string, avus []tasks.Metadata) error {
+
+	ifs := ctx.Value(dr.KeyFilesystem).(*fs.FileSystem)
+
+	metas, err := ifs.ListMetadata(ipath)
+	if err != nil {
+		return err
+	}
+
+	current := make(map[string]map[tasks.Metadata]bool)
+	for _, m := range metas {
+		if current[m.Name] == nil {
+			current[m.Name] = make(map[tasks.Metadata]bool)
+		}
+		current[m.Name][tasks.Metadata{Attribute: m.Name, Value: m.Value, Unit: m.Units}] = true
+	}
+
+	// new values grouped by attribute, in the order of the attributes in `avus`
+	var names []string
+	values := make(map[string]map[tasks.Metadata]bool)
+	for _, m := range avus {
+		if values[m.Attribute] == nil {
+			names = append(names, m.Attribute)
+			values[m.Attribute] = make(map[tasks.Metadata]bool)
+		}
+		values[m.Attribute][m] = true
+	}
+
+	for _, name := range names {
+		if reflect.DeepEqual(current[name], values[name]) {
+			continue
+		}
+		if current[name] != nil {
+			if err := ifs.DeleteMetadataByName(ipath, name); err != nil {
+				return err
+			}
+		}
+		for m := range values[name] {
+			if err := ifs.AddMetadata(ipath, m.Attribute, m.Value, m.Unit); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// recordFileAttrs keeps the modification time and the permission mode of the local file `f`
+// as metadata on the iRODS data object `ipath`.
+func recordFileAttrs(ctx context.Context, ipath string, f ppath.PathInfo) error {
+	return setMetadata(ctx, ipath, []tasks.Metadata{
+		{Attribute: ppath.ModTimeAttribute, Value: strconv.FormatInt(f.ModTime.Unix(), 10)},
+		{Attribute: ppath.ModeAttribute, Value: fmt.Sprintf("%04o", f.Mode.Perm())},
+	})
+}
+
+// copyFileAttrs copies the metadata of the local file properties from the iRODS data object
+// `src` to `idst`.
+func copyFileAttrs(ctx context.Context, src ppath.PathInfo, idst string) error {
+
+	metas, err := ctx.Value(dr.KeyFilesystem).(*fs.FileSystem).ListMetadata(src.Path)
+	if err != nil {
+		return err
+	}
+
+	var attrs []tasks.Metadata
+	for _, m := range metas {
+		if m.Name == ppath.ModTimeAttribute || m.Name == ppath.ModeAttribute {
+			attrs = append(attrs, tasks.Metadata{Attribute: m.Name, Value: m.Value, Unit: m.Units})
+		}
+	}
+
+	// the modify time of the source data object is recorded as the original modification
+	// time, so that the copy is identical to the source by the size and modification time.
+	if !hasAttribute(attrs, ppath.ModTimeAttribute) {
+		attrs = append(attrs, tasks.Metadata{Attribute: ppath.ModTimeAttribute, Value: strconv.FormatInt(src.ModTime.Unix(), 10)})
+	}
+
+	return setMetadata(ctx, idst, attrs)
+}
+
+// hasAttribute checks whether the attribute `name` is in the metadata `avus`.
+func hasAttribute(avus []tasks.Metadata, name string) bool {
+	for _, m := range avus {
+		if m.Attribute == name {
+			return true
+		}
+	}
+	return false
+}
+
+// restoreFileAttrs sets the modification time of the downloaded file `lpath` to the one of
+// the original local file recorded on the iRODS data object `f`, or to the modification time
+// of `f` in iRODS if it is not recorded.  The permission mode of `lpath` is set to `fileMode`.
+func restoreFileAttrs(ctx context.Context, lpath string, f ppath.PathInfo) error {
+
+	mtime := ppath.OriginalModTime(ctx, f)
+
+	if err := os.Chmod(lpath, fileMode); err != nil {
+		return err
+	}
+
+	return os.Chtimes(lpath, mtime, mtime)
+}
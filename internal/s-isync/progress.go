@@ -0,0 +1,121 @@
+package main
+
+import (
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dccn-tg/dr-data-stager/pkg/event"
+)
+
+var (
+	// events is the writer of the events reported to the worker via stdout.
+	events = event.NewWriter(os.Stdout)
+
+	// progressInterval is the interval at which the byte-level progress is reported
+	// while files are being transferred.
+	progressInterval = 5 * time.Second
+
+	// heartbeatInterval is the interval at which heartbeats are reported until
+	// the scan of the source is complete.
+	heartbeatInterval = 10 * time.Second
+)
+
+// byteCounter keeps track of the number of bytes processed by all sync workers.
+type byteCounter struct {
+	done atomic.Int64
+}
+
+// Add increases the counter by `n` bytes.
+func (c *byteCounter) Add(n int64) {
+	c.done.Add(n)
+}
+
+// Load returns the current number of bytes processed.
+func (c *byteCounter) Load() int64 {
+	return c.done.Load()
+}
+
+// scanCounter keeps track of the number of files and bytes found by the scanner, while
+// the files are being synced.
+type scanCounter struct {
+	files atomic.Int64
+	bytes atomic.Int64
+	done  chan struct{}
+}
+
+func newScanCounter() *scanCounter {
+	return &scanCounter{done: make(chan struct{})}
+}
+
+// Add counts a file of `size` bytes found by the scanner.
+func (c *scanCounter) Add(size int64) {
+	c.files.Add(1)
+	c.bytes.Add(size)
+}
+
+// Finish marks the scan as complete.
+func (c *scanCounter) Finish() {
+	close(c.done)
+}
+
+// Done returns a channel that is closed when the scan is complete.
+func (c *scanCounter) Done() <-chan struct{} {
+	return c.done
+}
+
+// Update sets the total of the progress `p` to the number of files and bytes found
+// so far.  The total is marked as provisional until the scan is complete.
+func (c *scanCounter) Update(p *event.Progress) {
+	// check the completion first, so that the counts are final if the scan is complete.
+	select {
+	case <-c.done:
+		p.Provisional = false
+	default:
+		p.Provisional = true
+	}
+	p.Total = c.files.Load()
+	p.TotalBytes = c.bytes.Load()
+}
+
+// fileTracker follows the transfer of a single file and forwards the increment
+// of transferred bytes to the shared `byteCounter`.
+type fileTracker struct {
+	mu      sync.Mutex
+	counter *byteCounter
+	last    int64
+}
+
+func newFileTracker(counter *byteCounter) *fileTracker {
+	return &fileTracker{counter: counter}
+}
+
+// Update is a `common.TrackerCallBack` of the go-irodsclient for receiving
+// the accumulated number of bytes transferred of the file.  It is called by the
+// parallel streams of a transfer concurrently, so that an update may arrive after
+// a later one; only the forward movement is counted.
+func (t *fileTracker) Update(processed, total int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if processed <= t.last {
+		return
+	}
+	t.counter.Add(processed - t.last)
+	t.last = processed
+}
+
+// Reset withdraws the bytes reported via `Update` from the counter, so that a retry of
+// the transfer reports its bytes from the start.
+func (t *fileTracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.counter.Add(-t.last)
+	t.last = 0
+}
+
+// Done marks the file of `size` bytes as processed, regardless of the number of
+// bytes reported via `Update`.
+func (t *fileTracker) Done(size int64) {
+	t.Update(size, size)
+}
@@ -0,0 +1,45 @@
+package main
+
+import "testing"
+
+func TestFileTracker(t *testing.T) {
+
+	type step struct {
+		// update with the processed bytes, or reset if negative
+		processed int64
+		expected  int64
+	}
+
+	cases := []struct {
+		name  string
+		steps []step
+	}{
+		{"forward", []step{{10, 10}, {30, 30}, {100, 100}}},
+		{"out of order", []step{{30, 30}, {10, 30}, {30, 30}, {50, 50}}},
+		{"reset", []step{{30, 30}, {-1, 0}, {10, 10}, {100, 100}}},
+		{"reset twice", []step{{30, 30}, {-1, 0}, {-1, 0}, {20, 20}}},
+	}
+
+	for _, c := range cases {
+		counter := &byteCounter{}
+		counter.Add(1000) // bytes of the other files
+
+		tracker := newFileTracker(counter)
+		for i, s := range c.steps {
+			if s.processed < 0 {
+				tracker.Reset()
+			} else {
+				tracker.Update(s.processed, 100)
+			}
+			if got := counter.Load() - 1000; got != s.expected {
+				t.Errorf("%s: step %d: expected %d, got %d", c.name, i, s.expected, got)
+			}
+		}
+
+		// the file is complete regardless of the bytes reported
+		tracker.Done(100)
+		if got := counter.Load() - 1000; got != 100 {
+			t.Errorf("%s: done: expected 100, got %d", c.name, got)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/cyverse/go-irodsclient/fs"
+	"github.com/cyverse/go-irodsclient/irods/common"
+	"github.com/cyverse/go-irodsclient/irods/types"
+	"github.com/dccn-tg/dr-data-stager/internal/worker/config"
+	"github.com/dccn-tg/dr-data-stager/pkg/dr"
+	log "github.com/dccn-tg/tg-toolset-golang/pkg/logger"
+)
+
+var (
+	// retries is the number of retries of a file transfer failed on a transient error.
+	retries int = config.DefaultFileRetries
+
+	// retryDelay is the delay before the first retry of a file transfer.
+	retryDelay time.Duration = config.DefaultFileRetryDelay
+)
+
+// maxRetryDelay caps the exponential backoff between the retries of a file transfer.
+const maxRetryDelay = 5 * time.Minute
+
+// irodsSession holds the iRODS filesystem of the task.  The filesystem is replaced by a new
+// one when its connections are broken, e.g. by a network outage or a restart of the iRODS
+// server, as go-irodsclient keeps failing on the connection error for a while.
+//
+// The replaced filesystems are only released at the end, as they may still be used by the
+// transfers in progress.
+type irodsSession struct {
+	mu      sync.Mutex
+	connect func() (*fs.FileSystem, error)
+	ifs     *fs.FileSystem
+	retired []*fs.FileSystem
+}
+
+// session is the iRODS session of the task.
+var session *irodsSession
+
+// newIrodsSession creates the session with the filesystem returned by `connect`, which is
+// also called for reconnecting the session.
+func newIrodsSession(connect func() (*fs.FileSystem, error)) (*irodsSession, error) {
+	ifs, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	return &irodsSession{connect: connect, ifs: ifs}, nil
+}
+
+// FileSystem returns the current filesystem of the session.
+func (s *irodsSession) FileSystem() *fs.FileSystem {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ifs
+}
+
+// Context returns a copy of `ctx` carrying the current filesystem of the session.
+func (s *irodsSession) Context(ctx context.Context) context.Context {
+	return context.WithValue(ctx, dr.KeyFilesystem, s.FileSystem())
+}
+
+// Reconnect replaces the `broken` filesystem with a new one.  Nothing is done if the
+// filesystem has been replaced already, e.g. by another worker hitting the same failure.
+func (s *irodsSession) Reconnect(broken *fs.FileSystem) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ifs != broken {
+		return nil
+	}
+
+	ifs, err := s.connect()
+	if err != nil {
+		return err
+	}
+
+	s.retired = append(s.retired, s.ifs)
+	s.ifs = ifs
+	return nil
+}
+
+// Release releases the current and the replaced filesystems of the session.
+func (s *irodsSession) Release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, ifs := range s.retired {
+		ifs.Release()
+	}
+	s.retired = nil
+	s.ifs.Release()
+}
+
+// failure is the class of an error of a file transfer.
+type failure int
+
+const (
+	// failurePermanent is an error that retrying won't resolve, e.g. permission denied.
+	failurePermanent failure = iota
+	// failureTransient is a temporary error of the iRODS service, e.g. too many connections.
+	failureTransient
+	// failureBroken is an error of a broken connection, the session is reconnected before
+	// the retry.
+	failureBroken
+)
+
+// classifyError determines whether the transfer failed with `err` is to be retried, and
+// whether the iRODS connections are broken.
+func classifyError(err error) failure {
+
+	switch {
+	case err == nil,
+		errors.Is(err, context.Canceled),
+		errors.Is(err, context.DeadlineExceeded),
+		types.IsPermanantFailure(err):
+		return failurePermanent
+	case types.IsConnectionPoolFullError(err):
+		return failureTransient
+	case types.IsConnectionError(err):
+		return failureBroken
+	}
+
+	// error returned by the iRODS server, the code may carry the errno in the last 3 digits.
+	if types.IsIRODSError(err) {
+		switch types.GetIRODSErrorCode(err) / 1000 * 1000 {
+		case common.SYS_EXCEED_CONNECT_CNT,
+			common.SYS_MAX_CONNECT_COUNT_EXCEEDED,
+			common.SYS_SVR_TO_SVR_CONNECT_FAILED,
+			common.CATALOG_NOT_CONNECTED,
+			common.CAT_CONNECT_ERR:
+			return failureTransient
+		case common.SYS_HEADER_READ_LEN_ERR,
+			common.SYS_SOCK_READ_TIMEDOUT,
+			common.SYS_SOCK_READ_ERR,
+			common.SYS_SOCK_WRITE_ERR,
+			common.SYS_SOCK_CONNECT_ERR,
+			common.USER_SOCK_CONNECT_ERR,
+			common.USER_SOCK_CONNECT_TIMEDOUT:
+			return failureBroken
+		default:
+			return failurePermanent
+		}
+	}
+
+	// network error on the connection to the iRODS server
+	var nerr net.Error
+	if errors.As(err, &nerr) {
+		return failureBroken
+	}
+
+	switch {
+	case errors.Is(err, io.EOF),
+		errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, net.ErrClosed),
+		errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, syscall.ECONNABORTED),
+		errors.Is(err, syscall.EPIPE),
+		errors.Is(err, syscall.ETIMEDOUT),
+		errors.Is(err, syscall.EHOSTUNREACH),
+		errors.Is(err, syscall.ENETUNREACH):
+		return failureBroken
+	}
+
+	return failurePermanent
+}
+
+// withRetry calls `transfer` of the file `p` until it succeeds, fails with a permanent error,
+// or `retries` retries are used up.  The retries are delayed with an exponential backoff
+// starting from `retryDelay`.  `transfer` is given a context carrying the current filesystem
+// of the session, which is reconnected if the previous attempt failed on a broken connection.
+func withRetry(ctx context.Context, p string, transfer func(ctx context.Context) error) error {
+
+	delay := retryDelay
+
+	for attempt := 0; ; attempt++ {
+
+		ifs := session.FileSystem()
+
+		err := transfer(context.WithValue(ctx, dr.KeyFilesystem, ifs))
+
+		class := classifyError(err)
+		if class == failurePermanent || attempt >= retries {
+			return err
+		}
+
+		log.Warnf("[%s] retry %s in %s (%d/%d): %s", taskID, p, delay, attempt+1, retries, err)
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+
+		if class == failureBroken {
+			if rerr := session.Reconnect(ifs); rerr != nil {
+				log.Errorf("[%s] cannot reconnect to iRODS: %s", taskID, rerr)
+			}
+		}
+
+		delay = nextDelay(delay)
+	}
+}
+
+// nextDelay returns the delay of the retry following the one delayed by `delay`, which is
+// doubled up to `maxRetryDelay`.
+func nextDelay(delay time.Duration) time.Duration {
+	if delay *= 2; delay > maxRetryDelay {
+		return maxRetryDelay
+	}
+	return delay
+}
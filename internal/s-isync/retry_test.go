@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/cyverse/go-irodsclient/fs"
+	"github.com/cyverse/go-irodsclient/irods/common"
+	"github.com/cyverse/go-irodsclient/irods/types"
+	"github.com/dccn-tg/dr-data-stager/pkg/dr"
+	log "github.com/dccn-tg/tg-toolset-golang/pkg/logger"
+)
+
+func init() {
+	// setup logger
+	log.NewLogger(
+		log.Configuration{
+			EnableConsole:     true,
+			ConsoleJSONFormat: false,
+			ConsoleLevel:      log.Debug,
+		},
+		log.InstanceLogrusLogger,
+	)
+}
+
+func TestClassifyError(t *testing.T) {
+
+	cases := []struct {
+		name     string
+		err      error
+		expected failure
+	}{
+		{"nil", nil, failurePermanent},
+		{"canceled", fmt.Errorf("get: %w", context.Canceled), failurePermanent},
+		{"deadline", context.DeadlineExceeded, failurePermanent},
+		{"not found", types.NewFileNotFoundError("/a"), failurePermanent},
+		{"permission", os.ErrPermission, failurePermanent},
+		{"plain", errors.New("something went wrong"), failurePermanent},
+		{"pool full", types.NewConnectionPoolFullError(4, 4), failureTransient},
+		{"connection", types.NewConnectionError(), failureBroken},
+		{"too many connections", types.NewIRODSError(common.SYS_MAX_CONNECT_COUNT_EXCEEDED), failureTransient},
+		{"socket read with errno", types.NewIRODSError(common.SYS_SOCK_READ_ERR - 104), failureBroken},
+		{"no permission", types.NewIRODSError(common.CAT_NO_ACCESS_PERMISSION), failurePermanent},
+		{"net", &net.OpError{Op: "read", Net: "tcp", Err: errors.New("i/o timeout")}, failureBroken},
+		{"eof", fmt.Errorf("read: %w", io.ErrUnexpectedEOF), failureBroken},
+		{"reset", fmt.Errorf("write: %w", syscall.ECONNRESET), failureBroken},
+	}
+
+	for _, c := range cases {
+		if got := classifyError(c.err); got != c.expected {
+			t.Errorf("%s: expected %d, got %d", c.name, c.expected, got)
+		}
+	}
+}
+
+func TestNextDelay(t *testing.T) {
+
+	cases := map[time.Duration]time.Duration{
+		time.Second:           2 * time.Second,
+		time.Minute:           2 * time.Minute,
+		3 * time.Minute:       maxRetryDelay,
+		maxRetryDelay:         maxRetryDelay,
+		2 * maxRetryDelay:     maxRetryDelay,
+		50 * time.Millisecond: 100 * time.Millisecond,
+	}
+
+	for delay, expected := range cases {
+		if got := nextDelay(delay); got != expected {
+			t.Errorf("%s: expected %s, got %s", delay, expected, got)
+		}
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+
+	// the filesystems are never used for a transfer, they only identify the connections.
+	var connected []*fs.FileSystem
+	connect := func() (*fs.FileSystem, error) {
+		ifs := new(fs.FileSystem)
+		connected = append(connected, ifs)
+		return ifs, nil
+	}
+
+	defer func(r int, d time.Duration, s *irodsSession) {
+		retries, retryDelay, session = r, d, s
+	}(retries, retryDelay, session)
+
+	retries, retryDelay = 2, time.Millisecond
+
+	cases := []struct {
+		name string
+		// errors of the consecutive attempts
+		errs []error
+		// expected error and number of attempts
+		err      error
+		attempts int
+		// expected number of connections
+		connects int
+	}{
+		{"success", []error{nil}, nil, 1, 1},
+		{"permanent", []error{os.ErrPermission}, os.ErrPermission, 1, 1},
+		{"transient", []error{types.NewConnectionPoolFullError(4, 4), nil}, nil, 2, 1},
+		{"broken", []error{io.EOF, io.EOF, nil}, nil, 3, 3},
+		{"retries used up", []error{io.EOF, io.EOF, io.EOF, nil}, io.EOF, 3, 3},
+	}
+
+	for _, c := range cases {
+		connected = nil
+
+		var err error
+		session, err = newIrodsSession(connect)
+		if err != nil {
+			t.Fatalf("%s\n", err)
+		}
+
+		// the filesystem given to each attempt
+		var used []*fs.FileSystem
+
+		err = withRetry(context.Background(), "/a", func(ctx context.Context) error {
+			used = append(used, ctx.Value(dr.KeyFilesystem).(*fs.FileSystem))
+			return c.errs[len(used)-1]
+		})
+
+		if !errors.Is(err, c.err) {
+			t.Errorf("%s: expected error %v, got %v", c.name, c.err, err)
+		}
+		if len(used) != c.attempts {
+			t.Errorf("%s: expected %d attempts, got %d", c.name, c.attempts, len(used))
+		}
+		if len(connected) != c.connects {
+			t.Errorf("%s: expected %d connections, got %d", c.name, c.connects, len(connected))
+		}
+
+		// an attempt after a broken connection is made with the reconnected filesystem
+		for i := 1; i < len(used); i++ {
+			broken := classifyError(c.errs[i-1]) == failureBroken
+			if reconnected := used[i] != used[i-1]; reconnected != broken {
+				t.Errorf("%s: attempt %d reconnected: %t, expected %t", c.name, i+1, reconnected, broken)
+			}
+		}
+		if session.FileSystem() != connected[len(connected)-1] {
+			t.Errorf("%s: session not on the last connection", c.name)
+		}
+	}
+}
+
+func TestReconnect(t *testing.T) {
+
+	n := 0
+	s, err := newIrodsSession(func() (*fs.FileSystem, error) {
+		n++
+		if n > 2 {
+			return nil, errors.New("connection refused")
+		}
+		return new(fs.FileSystem), nil
+	})
+	if err != nil {
+		t.Fatalf("%s\n", err)
+	}
+
+	first := s.FileSystem()
+	if err := s.Reconnect(first); err != nil {
+		t.Fatalf("%s\n", err)
+	}
+	second := s.FileSystem()
+	if second == first || len(s.retired) != 1 {
+		t.Errorf("broken filesystem not replaced")
+	}
+
+	// the filesystem has been replaced already, e.g. by another worker
+	if err := s.Reconnect(first); err != nil || s.FileSystem() != second || n != 2 {
+		t.Errorf("replaced filesystem reconnected again")
+	}
+
+	// the broken filesystem is kept if the reconnection fails
+	if err := s.Reconnect(second); err == nil || s.FileSystem() != second {
+		t.Errorf("failed reconnection not reported")
+	}
+}
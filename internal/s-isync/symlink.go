@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/cyverse/go-irodsclient/fs"
+	"github.com/dccn-tg/dr-data-stager/pkg/dr"
+	ppath "github.com/dccn-tg/dr-data-stager/pkg/path"
+	log "github.com/dccn-tg/tg-toolset-golang/pkg/logger"
+)
+
+// irodsLinkTarget returns the link target recorded on the placeholder data object `ipath`,
+// or an empty string if the data object is not a placeholder of a symbolic link.
+func irodsLinkTarget(ctx context.Context, ipath string) (string, error) {
+	metas, err := ctx.Value(dr.KeyFilesystem).(*fs.FileSystem).ListMetadata(ipath)
+	if err != nil {
+		return "", err
+	}
+
+	for _, m := range metas {
+		if m.Name == ppath.SymlinkAttribute {
+			return m.Value, nil
+		}
+	}
+	return "", nil
+}
+
+// placeholderTarget returns the link target if the iRODS data object `f` is the placeholder
+// of a symbolic link, otherwise an empty string.  Only empty data objects are checked.
+func placeholderTarget(ctx context.Context, f ppath.PathInfo) string {
+	if f.Size != 0 {
+		return ""
+	}
+
+	target, err := irodsLinkTarget(ctx, f.Path)
+	if err != nil {
+		log.Warnf("cannot get metadata of %s: %s\n", f.Path, err)
+		return ""
+	}
+	return target
+}
+
+// recordLink creates the empty placeholder data object `ipath` for a symbolic link, with the
+// link `target` recorded as metadata.  An existing data object at `ipath` is overwritten.  It
+// returns `true` if the placeholder is already recorded with the same target.
+func recordLink(ctx context.Context, ipath, target string) (bool, error) {
+
+	old, _ := irodsLinkTarget(ctx, ipath)
+	if old == target {
+		return true, nil
+	}
+
+	ifs := ctx.Value(dr.KeyFilesystem).(*fs.FileSystem)
+
+	fh, err := ifs.CreateFile(ipath, "", "w")
+	if err != nil {
+		return false, fmt.Errorf("cannot create placeholder %s: %w", ipath, err)
+	}
+	if err := fh.Close(); err != nil {
+		return false, fmt.Errorf("cannot create placeholder %s: %w", ipath, err)
+	}
+
+	// the metadata of the data object is kept when it is overwritten
+	if old != "" {
+		if err := ifs.DeleteMetadataByName(ipath, ppath.SymlinkAttribute); err != nil {
+			return false, fmt.Errorf("cannot remove link target from %s: %w", ipath, err)
+		}
+	}
+
+	if err := ifs.AddMetadata(ipath, ppath.SymlinkAttribute, target, ""); err != nil {
+		return false, fmt.Errorf("cannot record link target on %s: %w", ipath, err)
+	}
+
+	return false, nil
+}
+
+// isLink checks whether the file `fi` at `lpath` is a symbolic link referring to `target`.
+func isLink(lpath string, fi os.FileInfo, target string) bool {
+	if fi.Mode()&os.ModeSymlink == 0 {
+		return false
+	}
+	old, err := os.Readlink(lpath)
+	return err == nil && old == target
+}
+
+// restoreLink creates the symbolic link `lpath` referring to `target`.  An existing file at
+// `lpath`, left in place by the `conflicts` policy, is replaced.
+func restoreLink(lpath, target string) error {
+	if err := os.Remove(lpath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return os.Symlink(target, lpath)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRestoreLink(t *testing.T) {
+
+	dir := t.TempDir()
+
+	cases := []struct {
+		name string
+		// creates the existing file at the link path
+		setup func(p string) error
+	}{
+		{"new", func(p string) error { return nil }},
+		{"existing file", func(p string) error { return os.WriteFile(p, []byte("data"), 0644) }},
+		{"link to another target", func(p string) error { return os.Symlink("other.txt", p) }},
+	}
+
+	for _, c := range cases {
+		p := filepath.Join(dir, c.name)
+		if err := c.setup(p); err != nil {
+			t.Fatalf("%s: %s\n", c.name, err)
+		}
+
+		if fi, err := os.Lstat(p); err == nil && isLink(p, fi, "data.txt") {
+			t.Errorf("%s: unexpected link to data.txt", c.name)
+		}
+
+		if err := restoreLink(p, "data.txt"); err != nil {
+			t.Errorf("%s: %s", c.name, err)
+			continue
+		}
+
+		fi, err := os.Lstat(p)
+		if err != nil || !isLink(p, fi, "data.txt") {
+			t.Errorf("%s: link not restored", c.name)
+		}
+	}
+}
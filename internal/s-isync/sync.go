@@ -3,23 +3,127 @@ package main
 import (
 	"context"
 	"fmt"
+	"os"
 	"path"
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/cyverse/go-irodsclient/fs"
 	"github.com/dccn-tg/dr-data-stager/internal/worker/config"
 	"github.com/dccn-tg/dr-data-stager/pkg/dr"
+	"github.com/dccn-tg/dr-data-stager/pkg/event"
+	"github.com/dccn-tg/dr-data-stager/pkg/tasks"
 
 	ppath "github.com/dccn-tg/dr-data-stager/pkg/path"
 	log "github.com/dccn-tg/tg-toolset-golang/pkg/logger"
 )
 
-// syncOutput registers the error message of a particular file sync error.
+// resumeThreshold is the file size in bytes above which the download from iRODS
+// is performed in a resumable way.
+var resumeThreshold int64 = 1 << 30
+
+// streams limits the concurrent transfer streams of all sync workers.
+var streams *streamLimiter
+
+// bandwidth limits the data transfer rate of all sync workers.
+var bandwidth *throttle
+
+// comparator determines whether a file at the destination is identical to the source file.
+var comparator ppath.Comparator = ppath.ChecksumComparator{}
+
+// conflicts is the policy applied to a file existing at the destination that differs from
+// the source file.
+var conflicts ppath.ConflictPolicy = ppath.ConflictOverwrite
+
+// syncOutput registers the outcome of syncing a particular file.
 type syncOutput struct {
-	File  string
-	Error error
+	File     string
+	DstFile  string
+	Size     int64
+	ModTime  time.Time
+	Checksum string
+	Skipped  bool
+	Deleted  bool
+	Error    error
+	// Link is the target of the symbolic link, if the file is a link not being followed.
+	Link string
+	// LinkSkipped indicates that the file is a symbolic link skipped by the symlink policy.
+	LinkSkipped bool
+	// Plan is the action planned on the file in the dry-run mode.
+	Plan string
+	// Bundled indicates that the file is uploaded in the bundle archive `DstFile`.
+	Bundled bool
+	// Unpacked are the local files of the bundle archive `File`, extracted or kept by the
+	// conflict policy.
+	Unpacked []string
+	// Conflict is the policy applied to the file as it exists at the destination and differs.
+	Conflict ppath.ConflictPolicy
+	// Renamed is the path to which the existing file at the destination is renamed.
+	Renamed string
+}
+
+// plan returns the output of the file `out` to be transferred in the dry-run mode.  The
+// file is planned to be overwritten if it `exists` at the destination, unless the existing
+// file is to be renamed by the conflict policy.
+func plan(out syncOutput, exists bool, counter *byteCounter) syncOutput {
+	out.Plan = tasks.ActionCopy
+	if exists && out.Conflict != ppath.ConflictRename {
+		out.Plan = tasks.ActionOverwrite
+	}
+	log.Debugf("plan %s: %s -> %s\n", out.Plan, out.File, out.DstFile)
+	counter.Add(out.Size)
+	return out
+}
+
+// resolveConflict applies the `conflicts` policy to the file `out` of which the destination
+// `dst` exists and differs from the source.  It returns `true` if the file is not to be
+// transferred, i.e. it is skipped or failed.  The existing file is renamed by the
+// `ConflictRename` policy, except in the dry-run mode.
+func resolveConflict(ctx context.Context, out *syncOutput, dst ppath.PathInfo, counter *byteCounter) bool {
+
+	out.Conflict = conflicts
+
+	switch conflicts {
+	case ppath.ConflictSkip:
+		log.Debugf("skip transfer: %s exists\n", out.DstFile)
+		out.Skipped = true
+	case ppath.ConflictFail:
+		out.Error = fmt.Errorf("destination exists: %s", out.DstFile)
+	case ppath.ConflictRename:
+		if dryRun {
+			return false
+		}
+		out.Renamed = ppath.RenamedPath(dst.Path, time.Now())
+		log.Debugf("rename: %s -> %s\n", dst.Path, out.Renamed)
+		if out.Error = renameFile(ctx, dst, out.Renamed); out.Error == nil {
+			return false
+		}
+	default:
+		return false
+	}
+
+	counter.Add(out.Size)
+	return true
+}
+
+// renameFile renames the file `f` on the local filesystem or in iRODS to `dst`, which must
+// not exist.
+func renameFile(ctx context.Context, f ppath.PathInfo, dst string) error {
+	switch f.Type {
+	case ppath.TypeIrods:
+		ifs := ctx.Value(dr.KeyFilesystem).(*fs.FileSystem)
+		if ifs.Exists(dst) {
+			return fmt.Errorf("cannot rename %s: %s exists", f.Path, dst)
+		}
+		return ifs.RenameFileToFile(f.Path, dst)
+	default:
+		if _, err := os.Lstat(dst); err == nil {
+			return fmt.Errorf("cannot rename %s: %s exists", f.Path, dst)
+		}
+		return os.Rename(f.Path, dst)
+	}
 }
 
 // scanAndSync walks through the files retrieved from the `bufio.Scanner`,
@@ -30,23 +134,66 @@ type syncOutput struct {
 //
 // Files being successfully synced will be returned as a map with key as the filename
 // and value as the checksum of the file.
-func scanAndSync(ctx context.Context, config config.Configuration, src, dst ppath.PathInfo, nworkers int) (processed chan syncOutput) {
+//
+// Bytes being transferred are accumulated to the `counter`.  Files recorded in the
+// checkpoint journal `jnl` are skipped.
+//
+// The files are synced while the scan is in progress.  Files found by the scanner are
+// counted by the `scan` counter, which is marked as finished at the end of the scan.
+func scanAndSync(ctx context.Context, config config.Configuration, src, dst ppath.PathInfo, nworkers int, counter *byteCounter, scan *scanCounter, jnl *journal) (processed chan syncOutput) {
 
 	processed = make(chan syncOutput)
 
 	// initiate a source scanner and performs the scan.
-	scanner := ppath.NewScanner(src)
-	dirmaker := ppath.NewDirMaker(dst, config)
+	scanner := ppath.NewScanner(src, filter, links)
 
-	files := scanner.ScanMakeDir(ctx, nworkers*8, &dirmaker)
+	// no directory is created in the dry-run mode
+	var dirmaker *ppath.DirMaker
+	if !dryRun {
+		dm := ppath.NewDirMaker(dst, config.Process.DirPerm())
+		dirmaker = &dm
+	}
+
+	scanned := scanner.ScanMakeDir(ctx, nworkers*8, dirmaker)
 
 	// create worker group
 	var wg sync.WaitGroup
+
+	// count the files on their way from the scanner to the sync workers.  Small files set
+	// aside by the bundler are synced in bundles after the scan is complete.
+	bundler := newBundler(src, dst)
+	files := make(chan ppath.PathInfo, nworkers*8)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		aborted := func() bool {
+			defer close(files)
+			defer scan.Finish()
+			for f := range scanned {
+				scan.Add(f.Size)
+				if bundler.Add(f) {
+					continue
+				}
+				select {
+				case files <- f:
+				case <-ctx.Done():
+					return true
+				}
+			}
+			return false
+		}()
+
+		if !aborted {
+			bundler.Sync(ctx, processed, counter)
+		}
+	}()
+
 	wg.Add(nworkers)
 
 	// spin off workers
 	for i := 1; i <= nworkers; i++ {
-		go syncWorker(ctx, &wg, src, dst, files, processed)
+		go syncWorker(ctx, &wg, src, dst, files, processed, counter, jnl)
 	}
 
 	go func() {
@@ -59,12 +206,32 @@ func scanAndSync(ctx context.Context, config config.Configuration, src, dst ppat
 	return
 }
 
+// dstPathOf returns the destination path of the source file `fsrc` synced from `src` to `dst`.
+func dstPathOf(src, dst ppath.PathInfo, fsrc string) string {
+
+	// destination isn't a directory, then it should be used as the destination file path.
+	// Note: !! missing parent directories of `dst.Path` will throw error in transfer !!
+	if src.Mode.IsRegular() && !dst.Mode.IsDir() {
+		return dst.Path
+	}
+
+	// `dst` is an existing directory/collection, construct the destination file path of
+	// this particular file.
+	srcbase := src.Path
+	if src.Mode.IsRegular() {
+		srcbase = filepath.Dir(src.Path)
+	}
+	return path.Join(dst.Path, strings.TrimPrefix(fsrc, srcbase))
+}
+
 func syncWorker(
 	ctx context.Context,
 	wg *sync.WaitGroup,
 	src, dst ppath.PathInfo,
-	files chan string,
-	processed chan syncOutput) {
+	files chan ppath.PathInfo,
+	processed chan syncOutput,
+	counter *byteCounter,
+	jnl *journal) {
 
 	// determin the basedir of the source
 	srcbase := src.Path
@@ -74,7 +241,7 @@ func syncWorker(
 
 	for {
 		select {
-		case fsrc, more := <-files:
+		case f, more := <-files:
 
 			// files channel is closed.
 			if !more {
@@ -82,73 +249,379 @@ func syncWorker(
 				return
 			}
 
+			// the filesystem of the session may have been reconnected by the retry of
+			// another file transfer.
+			ctx := session.Context(ctx)
+
+			fsrc := f.Path
+
 			// construct the destination path of this particular source `fsrc`
-			var fdst string
-			if src.Mode.IsRegular() && !dst.Mode.IsDir() {
-				// destination isn't a directory, then it should be used as the destination file path.
-				// Note: !! missing parent directories of `dst.Path` will throw error in transfer !!
-				fdst = dst.Path
-			} else {
-				// `dst` is an existing directory/collection, construct the
-				// destination file path of this particular file.
-				fdst = path.Join(dst.Path, strings.TrimPrefix(fsrc, srcbase))
+			fdst := dstPathOf(src, dst, fsrc)
+
+			out := syncOutput{
+				File:    fsrc,
+				DstFile: fdst,
+				Size:    f.Size,
+				ModTime: f.ModTime,
+			}
+
+			// symbolic link in the local source that is not followed by the scanner
+			if f.IsSymlink() {
+				out.Link = f.LinkTarget
+				switch {
+				case links == ppath.SymlinkRecord && dst.Type == ppath.TypeIrods && dryRun:
+					out = plan(out, false, counter)
+				case links == ppath.SymlinkRecord && dst.Type == ppath.TypeIrods:
+					log.Debugf("irods link: %s -> %s\n", fsrc, fdst)
+					out.Skipped, out.Error = recordLink(ctx, fdst, f.LinkTarget)
+				default:
+					log.Warnf("skip symlink: %s\n", fsrc)
+					out.Skipped = true
+					out.LinkSkipped = true
+				}
+				processed <- out
+				continue
+			}
+
+			// skip files synced by the previous attempts of the task
+			if e, ok := jnl.Completed(f, fdst); ok {
+				log.Debugf("skip transfer: %s synced by previous attempt\n", fsrc)
+				counter.Add(f.Size)
+				out.Checksum = e.Checksum
+				out.Skipped = true
+				processed <- out
+				continue
 			}
 
 			switch {
 			case src.Type == ppath.TypeIrods && dst.Type == ppath.TypeFileSystem:
 
-				psrc, _ := ppath.GetPathInfo(ctx, fmt.Sprintf("i:%s", fsrc))
-				pdst, _ := ppath.GetPathInfo(ctx, fdst)
+				psrc := f
 
-				if pdst.SameAs(ctx, psrc) {
-					log.Debugf("skip transfer: %s == %s\n", fsrc, fdst)
-					processed <- syncOutput{
-						File:  fsrc,
-						Error: nil,
+				// the bundle archives are unpacked in place of their indices
+				if unpack && isBundleIndex(fsrc) {
+					counter.Add(psrc.Size)
+					out.Skipped = true
+					processed <- out
+					continue
+				}
+
+				if unpack && isBundle(fsrc) {
+					if dryRun {
+						processed <- plan(out, false, counter)
+						continue
+					}
+					if out.Error = space.Reserve(psrc.Size, 0); out.Error != nil {
+						counter.Add(psrc.Size)
+						processed <- out
+						continue
+					}
+					log.Debugf("irods unpack: %s -> %s\n", fsrc, filepath.Dir(fdst))
+					events.Emit(event.Event{Type: event.TypeFileStarted, File: fsrc, DstFile: fdst, Size: psrc.Size})
+
+					// the files unpacked by a failed attempt are identical, and kept by the retry.
+					tracker := newFileTracker(counter)
+					out.Error = withRetry(ctx, fsrc, func(ctx context.Context) error {
+						tracker.Reset()
+						unpacked, conflict, err := unpackBundle(ctx, fsrc, filepath.Dir(fdst), psrc.Size, tracker.Update)
+						out.Unpacked = append(out.Unpacked, unpacked...)
+						if conflict {
+							out.Conflict = conflicts
+						}
+						return err
+					})
+					tracker.Done(psrc.Size)
+					processed <- out
+					continue
+				}
+
+				// restore the symbolic link recorded on the placeholder data object
+				if target := placeholderTarget(ctx, psrc); target != "" {
+					out.Link = target
+
+					// an existing file other than the same link is handled by the `conflicts`
+					// policy, like the destination of a download.
+					fi, err := os.Lstat(fdst)
+					if err == nil && isLink(fdst, fi, target) {
+						log.Debugf("skip link: %s -> %s\n", fdst, target)
+						out.Skipped = true
+						processed <- out
+						continue
+					}
+					if err == nil {
+						pdst := ppath.PathInfo{Path: fdst, Type: ppath.TypeFileSystem, Mode: fi.Mode(), Size: fi.Size(), ModTime: fi.ModTime()}
+						if resolveConflict(ctx, &out, pdst, counter) {
+							processed <- out
+							continue
+						}
 					}
+
+					if dryRun {
+						processed <- plan(out, err == nil, counter)
+						continue
+					}
+					log.Debugf("restore link: %s -> %s\n", fdst, target)
+					out.Error = restoreLink(fdst, target)
+					processed <- out
+					continue
+				}
+
+				pdst, err := ppath.GetPathInfo(ctx, fdst)
+
+				out.Checksum = psrc.GetChecksum()
+
+				if comparator.SameAs(ctx, &pdst, &psrc) {
+					log.Debugf("skip transfer: %s == %s\n", fsrc, fdst)
+					counter.Add(psrc.Size)
+					out.Skipped = true
+					processed <- out
+					continue
+				}
+
+				// the destination exists and differs from the source
+				if err == nil && resolveConflict(ctx, &out, pdst, counter) {
+					processed <- out
+					continue
+				}
+
+				if dryRun {
+					processed <- plan(out, err == nil, counter)
 					continue
 				}
 
+				if out.Error = space.Reserve(psrc.Size, replacedSize(out, pdst, err)); out.Error != nil {
+					counter.Add(psrc.Size)
+					processed <- out
+					continue
+				}
+
+				// the download is checked against the checksum registered on the data object.
+				// Only with `verify`, the checksum is computed by iRODS if it is not registered,
+				// as it reads the whole data object on the server.
+				ichksum := psrc.RegisteredChecksum()
+				if ichksum.IsEmpty() && verify {
+					ichksum, err = irodsChecksum(ctx, fsrc)
+					if err != nil {
+						out.Error = fmt.Errorf("cannot get checksum of %s: %w", fsrc, err)
+						counter.Add(psrc.Size)
+						processed <- out
+						continue
+					}
+					out.Checksum = ichksum.String()
+				}
+
+				// the digest of the download is computed with the algorithm of the registered
+				// checksum, and cached on the local file.
+				alg := ichksum.Algorithm
+				if ichksum.IsEmpty() {
+					alg = ppath.DefaultChecksumAlgorithm
+				}
+
 				// get file from irods
 				log.Debugf("irods get: %s -> %s\n", fsrc, fdst)
+				events.Emit(event.Event{Type: event.TypeFileStarted, File: fsrc, DstFile: fdst, Size: psrc.Size})
 
-				_, err := ctx.Value(dr.KeyFilesystem).(*fs.FileSystem).DownloadFile(fsrc, "", fdst, true, nil)
+				tracker := newFileTracker(counter)
+				var digest ppath.Checksum
+				err = withRetry(ctx, fsrc, func(ctx context.Context) (err error) {
+					tracker.Reset()
+					digest, err = download(ctx, fsrc, fdst, psrc.Size, alg, tracker.Update)
+					return
+				})
+				tracker.Done(psrc.Size)
 
-				processed <- syncOutput{
-					File:  fsrc,
-					Error: err,
+				// continue with the filesystem reconnected by the retries, if any.
+				ctx = session.Context(ctx)
+
+				out.Error = err
+				if out.Error == nil {
+					out.Error = checkDigest(fdst, fsrc, digest, ichksum)
+				}
+
+				if out.Error == nil && verify && !digest.Equal(ichksum) {
+					log.Debugf("verify: %s -> %s\n", fsrc, fdst)
+					events.Emit(event.Event{Type: event.TypePhase, Phase: event.PhaseVerifying, File: fsrc, DstFile: fdst})
+					_, out.Error = verifyChecksum(ctx, fdst, fsrc)
+				}
+
+				if out.Error == nil {
+					if err := restoreFileAttrs(ctx, fdst, psrc); err != nil {
+						log.Warnf("cannot restore modification time and mode of %s: %s\n", fdst, err)
+					}
+					cacheDigest(ctx, fdst, digest)
 				}
 
+				processed <- out
+
 			case src.Type == ppath.TypeFileSystem && dst.Type == ppath.TypeIrods:
 
-				pdst, _ := ppath.GetPathInfo(ctx, fmt.Sprintf("i:%s", fdst))
-				psrc, _ := ppath.GetPathInfo(ctx, fsrc)
+				pdst, err := ppath.GetPathInfo(ctx, fmt.Sprintf("i:%s", fdst))
+				psrc := f
 
-				if pdst.SameAs(ctx, psrc) {
+				if comparator.SameAs(ctx, &pdst, &psrc) {
 					log.Debugf("skip transfer: %s == %s\n", fsrc, fdst)
-					processed <- syncOutput{
-						File:  fsrc,
-						Error: nil,
-					}
+					counter.Add(psrc.Size)
+					out.Checksum = pdst.GetChecksum()
+					out.Skipped = true
+					processed <- out
+					continue
+				}
+
+				// the destination exists and differs from the source
+				if err == nil && resolveConflict(ctx, &out, pdst, counter) {
+					processed <- out
+					continue
+				}
+
+				if dryRun {
+					processed <- plan(out, err == nil, counter)
+					continue
+				}
+
+				if out.Error = space.Reserve(psrc.Size, replacedSize(out, pdst, err)); out.Error != nil {
+					counter.Add(psrc.Size)
+					processed <- out
 					continue
 				}
 
 				// put file to irods
 				log.Debugf("irods put: %s -> %s\n", fsrc, fdst)
+				events.Emit(event.Event{Type: event.TypeFileStarted, File: fsrc, DstFile: fdst, Size: psrc.Size})
+
+				tracker := newFileTracker(counter)
+				var digest ppath.Checksum
+				err = withRetry(ctx, fsrc, func(ctx context.Context) (err error) {
+					tracker.Reset()
+					digest, err = upload(ctx, fsrc, fdst, psrc.Size, tracker.Update)
+					return
+				})
+				tracker.Done(psrc.Size)
 
-				_, err := ctx.Value(dr.KeyFilesystem).(*fs.FileSystem).UploadFile(fsrc, fdst, "", false, true, true, nil)
+				// continue with the filesystem reconnected by the retries, if any.
+				ctx = session.Context(ctx)
 
-				processed <- syncOutput{
-					File:  fsrc,
-					Error: err,
+				// make sure the uploaded data object has a registered checksum, and compare it
+				// with the digest of the uploaded data.
+				var ichksum ppath.Checksum
+				out.Error = err
+				if out.Error == nil {
+					if ichksum, err = irodsChecksum(ctx, fdst); err != nil {
+						log.Warnf("cannot register checksum of %s: %s\n", fdst, err)
+					} else {
+						out.Checksum = ichksum.String()
+						out.Error = checkDigest(fsrc, fdst, digest, ichksum)
+					}
 				}
 
-			default:
-				// both source/destination has the same type
-				processed <- syncOutput{
-					File:  fsrc,
-					Error: fmt.Errorf("not supported"),
+				if out.Error == nil && verify && !digest.Equal(ichksum) {
+					log.Debugf("verify: %s -> %s\n", fsrc, fdst)
+					events.Emit(event.Event{Type: event.TypePhase, Phase: event.PhaseVerifying, File: fsrc, DstFile: fdst})
+					out.Checksum, out.Error = verifyChecksum(ctx, fsrc, fdst)
+				}
+
+				if out.Error == nil {
+					if err := psrc.CacheChecksum(digest); err != nil {
+						log.Debugf("cannot cache checksum of %s: %s\n", fsrc, err)
+					}
+					if err := recordFileAttrs(ctx, fdst, psrc); err != nil {
+						log.Warnf("cannot record modification time and mode of %s: %s\n", fsrc, err)
+					}
+					out.Error = applyMetadata(ctx, fdst, fileFields(psrc, strings.TrimPrefix(fsrc, srcbase)))
 				}
+
+				processed <- out
+
+			case src.Type == ppath.TypeIrods && dst.Type == ppath.TypeIrods:
+
+				psrc := f
+
+				// the link target recorded on the placeholder data object is not copied
+				// along with the data object, it is recorded on the destination again.
+				if target := placeholderTarget(ctx, psrc); target != "" {
+					out.Link = target
+					if dryRun {
+						processed <- plan(out, false, counter)
+						continue
+					}
+					log.Debugf("irods link: %s -> %s\n", fsrc, fdst)
+					out.Skipped, out.Error = recordLink(ctx, fdst, target)
+					processed <- out
+					continue
+				}
+
+				pdst, err := ppath.GetPathInfo(ctx, fmt.Sprintf("i:%s", fdst))
+
+				out.Checksum = psrc.GetChecksum()
+
+				if comparator.SameAs(ctx, &pdst, &psrc) {
+					log.Debugf("skip transfer: %s == %s\n", fsrc, fdst)
+					counter.Add(psrc.Size)
+					out.Skipped = true
+					processed <- out
+					continue
+				}
+
+				// the destination exists and differs from the source
+				if err == nil && resolveConflict(ctx, &out, pdst, counter) {
+					processed <- out
+					continue
+				}
+
+				if dryRun {
+					processed <- plan(out, err == nil, counter)
+					continue
+				}
+
+				if out.Error = space.Reserve(psrc.Size, replacedSize(out, pdst, err)); out.Error != nil {
+					counter.Add(psrc.Size)
+					processed <- out
+					continue
+				}
+
+				// server-side copy within iRODS, the data doesn't go through the worker.
+				log.Debugf("irods cp: %s -> %s\n", fsrc, fdst)
+				events.Emit(event.Event{Type: event.TypeFileStarted, File: fsrc, DstFile: fdst, Size: psrc.Size})
+
+				out.Error = withRetry(ctx, fsrc, func(ctx context.Context) error {
+					if err := streams.Acquire(ctx, 1); err != nil {
+						return err
+					}
+					defer streams.Release(1)
+					return ctx.Value(dr.KeyFilesystem).(*fs.FileSystem).CopyFileToFile(fsrc, fdst, true)
+				})
+				counter.Add(psrc.Size)
+
+				// continue with the filesystem reconnected by the retries, if any.
+				ctx = session.Context(ctx)
+
+				// make sure the copied data object has a registered checksum
+				if out.Error == nil {
+					if chksum, err := irodsChecksum(ctx, fdst); err != nil {
+						log.Warnf("cannot register checksum of %s: %s\n", fdst, err)
+					} else {
+						out.Checksum = chksum.String()
+					}
+				}
+
+				if out.Error == nil && verify {
+					log.Debugf("verify: %s -> %s\n", fsrc, fdst)
+					events.Emit(event.Event{Type: event.TypePhase, Phase: event.PhaseVerifying, File: fsrc, DstFile: fdst})
+					out.Checksum, out.Error = verifyIrodsChecksum(ctx, fsrc, fdst)
+				}
+
+				if out.Error == nil {
+					if err := copyFileAttrs(ctx, psrc, fdst); err != nil {
+						log.Warnf("cannot copy modification time and mode of %s: %s\n", fsrc, err)
+					}
+				}
+
+				processed <- out
+
+			default:
+				// both source/destination are local filesystem
+				counter.Add(f.Size)
+				out.Error = fmt.Errorf("not supported")
+				processed <- out
 			}
 		case <-ctx.Done():
 			log.Debugf("sync worker aborted")
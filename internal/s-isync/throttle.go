@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/cyverse/go-irodsclient/irods/common"
+	log "github.com/dccn-tg/tg-toolset-golang/pkg/logger"
+	"golang.org/x/time/rate"
+)
+
+// throttle limits the bandwidth of the data transfer of all sync workers.  The limit
+// is in bytes per second, and can be changed while the transfer is in progress.
+type throttle struct {
+	limiter *rate.Limiter
+}
+
+// newThrottle creates a throttle with the `limit` in bytes per second; 0 for no limit.
+func newThrottle(limit int64) *throttle {
+	t := &throttle{limiter: rate.NewLimiter(rate.Inf, 0)}
+	t.SetLimit(limit)
+	return t
+}
+
+// SetLimit changes the bandwidth limit to `limit` bytes per second; 0 for no limit.
+// The burst is the amount of data allowed in one second.
+func (t *throttle) SetLimit(limit int64) {
+	if limit <= 0 {
+		t.limiter.SetLimit(rate.Inf)
+		return
+	}
+	t.limiter.SetBurst(int(limit))
+	t.limiter.SetLimit(rate.Limit(limit))
+}
+
+// Reader wraps `r` into a reader that reads from `r` at the rate of the bandwidth limit.
+func (t *throttle) Reader(ctx context.Context, r io.Reader) io.Reader {
+	return &throttledReader{ctx: ctx, r: r, limiter: t.limiter}
+}
+
+// Callback wraps the `callback` of a transfer by go-irodsclient into a callback that blocks
+// the stream reporting the progress until the reported bytes are within the bandwidth limit.
+// It throttles the parallel and resumable transfers, of which the data doesn't pass through
+// a `Reader` of the throttle.
+//
+// The progress reported before any data is transferred, i.e. the offset of a resumed
+// transfer, is not throttled.
+func (t *throttle) Callback(ctx context.Context, callback common.TrackerCallBack) common.TrackerCallBack {
+	var mu sync.Mutex
+	var last int64
+
+	return func(processed, total int64) {
+		// the parallel streams report the accumulated bytes of all streams, not necessarily
+		// in order.
+		mu.Lock()
+		var n int64
+		if last > 0 && processed > last {
+			n = processed - last
+		}
+		if processed > last {
+			last = processed
+		}
+		mu.Unlock()
+
+		if n > 0 {
+			if err := wait(ctx, t.limiter, n); err != nil {
+				log.Debugf("%s\n", err)
+			}
+		}
+
+		if callback != nil {
+			callback(processed, total)
+		}
+	}
+}
+
+// Follow reads the updated bandwidth limits in bytes per second, one per line, from `r`
+// until `r` is closed.  It is used by the worker to share its bandwidth cap among the
+// concurrent s-isync processes.
+func (t *throttle) Follow(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		limit, err := strconv.ParseInt(strings.TrimSpace(scanner.Text()), 10, 64)
+		if err != nil {
+			log.Errorf("invalid bandwidth limit: %s\n", scanner.Text())
+			continue
+		}
+		log.Debugf("bandwidth limit: %d bytes/s\n", limit)
+		t.SetLimit(limit)
+	}
+}
+
+// throttledReader is a rate-limited `io.Reader`.
+type throttledReader struct {
+	ctx     context.Context
+	r       io.Reader
+	limiter *rate.Limiter
+}
+
+func (r *throttledReader) Read(p []byte) (int, error) {
+	// a single read is not larger than the burst, otherwise it never gets the
+	// tokens from the limiter.
+	if b := r.limiter.Burst(); r.limiter.Limit() != rate.Inf && b > 0 && len(p) > b {
+		p = p[:b]
+	}
+
+	n, err := r.r.Read(p)
+	if n <= 0 {
+		return n, err
+	}
+
+	if werr := wait(r.ctx, r.limiter, int64(n)); werr != nil {
+		return n, werr
+	}
+	return n, err
+}
+
+// wait blocks until the `limiter` allows `n` bytes, or the context is done.  The limit may
+// be changed in the meantime, the tokens are taken in pieces of at most the burst.
+func wait(ctx context.Context, limiter *rate.Limiter, n int64) error {
+	for n > 0 {
+		if limiter.Limit() == rate.Inf {
+			return nil
+		}
+		k := n
+		if b := int64(limiter.Burst()); b > 0 && k > b {
+			k = b
+		}
+		if err := limiter.WaitN(ctx, int(k)); err != nil {
+			return err
+		}
+		n -= k
+	}
+	return nil
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"context"
+	"hash"
+	"io"
+	"os"
+
+	"github.com/cyverse/go-irodsclient/fs"
+	"github.com/cyverse/go-irodsclient/irods/common"
+	"github.com/dccn-tg/dr-data-stager/pkg/dr"
+	ppath "github.com/dccn-tg/dr-data-stager/pkg/path"
+	log "github.com/dccn-tg/tg-toolset-golang/pkg/logger"
+	"golang.org/x/sync/semaphore"
+)
+
+// streamLimiter bounds the number of concurrent transfer streams of the task, so that
+// the number of iRODS connections used for data transfer stays bounded.
+type streamLimiter struct {
+	sem *semaphore.Weighted
+	max int
+}
+
+func newStreamLimiter(max int) *streamLimiter {
+	if max < 1 {
+		max = 1
+	}
+	return &streamLimiter{
+		sem: semaphore.NewWeighted(int64(max)),
+		max: max,
+	}
+}
+
+// Threads returns the number of streams for transferring a file of `size` bytes.  A file
+// larger than `parallelThreshold` is transferred with `parallelThreads` streams, up to the
+// maximum number of streams of the limiter.
+func (l *streamLimiter) Threads(size int64) int {
+	if parallelThreads <= 1 || size < parallelThreshold {
+		return 1
+	}
+	if parallelThreads > l.max {
+		return l.max
+	}
+	return parallelThreads
+}
+
+// Acquire blocks until `n` streams are available, or the context is done.
+func (l *streamLimiter) Acquire(ctx context.Context, n int) error {
+	return l.sem.Acquire(ctx, int64(n))
+}
+
+// Release returns `n` streams to the limiter.
+func (l *streamLimiter) Release(n int) {
+	l.sem.Release(int64(n))
+}
+
+// download gets the iRODS data object `src` of `size` bytes to the local file `dst`.  The data
+// is written to the temporary file `partialPath(dst)`, which is renamed to `dst` only after a
+// successful download.  The temporary file of a failed download is removed, unless the download
+// can be resumed by a retry of the task.  See `getFile` for the returned digest.
+func download(ctx context.Context, src, dst string, size int64, alg ppath.ChecksumAlgorithm, callback common.TrackerCallBack) (ppath.Checksum, error) {
+
+	part := partialPath(dst)
+	partials.Add(part, ppath.TypeFileSystem)
+
+	digest, err := getFile(ctx, src, part, size, alg, callback)
+	if err != nil {
+		if size < resumeThreshold {
+			os.Remove(part)
+		}
+		return digest, err
+	}
+
+	return digest, os.Rename(part, dst)
+}
+
+// upload puts the local file `src` of `size` bytes to the iRODS data object `dst`.  The data
+// is written to the temporary data object `partialPath(dst)`, which is renamed to `dst` only
+// after a successful upload.  See `putFile` for the returned digest.
+func upload(ctx context.Context, src, dst string, size int64, callback common.TrackerCallBack) (ppath.Checksum, error) {
+
+	tmp := partialPath(dst)
+	partials.Add(tmp, ppath.TypeIrods)
+
+	digest, err := putFile(ctx, src, tmp, size, callback)
+	if err != nil {
+		if rerr := ctx.Value(dr.KeyFilesystem).(*fs.FileSystem).RemoveFile(tmp, true); rerr != nil {
+			log.Debugf("cannot remove %s: %s\n", tmp, rerr)
+		}
+		return digest, err
+	}
+
+	return digest, replaceObject(ctx, tmp, dst)
+}
+
+// getFile gets the iRODS data object `src` of `size` bytes to the local file `dst`.
+//
+// A large file is downloaded with parallel streams, and in a resumable way if it is larger
+// than the `resumeThreshold`, so that the retry of the task continues from the last offset
+// written to the disk.  These downloads are verified by go-irodsclient, which reads the local
+// file again.  Other files are downloaded with a single stream, and hashed with the algorithm
+// `alg` while being written.  The digest is returned for a streamed download, otherwise it is
+// empty.  All downloads are rate-limited if the bandwidth is limited.
+func getFile(ctx context.Context, src, dst string, size int64, alg ppath.ChecksumAlgorithm, callback common.TrackerCallBack) (ppath.Checksum, error) {
+
+	threads := streams.Threads(size)
+	if threads == 1 && size < resumeThreshold {
+		return downloadStream(ctx, src, dst, size, alg, callback)
+	}
+
+	callback = bandwidth.Callback(ctx, callback)
+
+	if err := streams.Acquire(ctx, threads); err != nil {
+		return ppath.Checksum{}, err
+	}
+	defer streams.Release(threads)
+
+	ifs := ctx.Value(dr.KeyFilesystem).(*fs.FileSystem)
+
+	var err error
+	switch {
+	case threads > 1 && size >= resumeThreshold:
+		log.Debugf("parallel resumable download with %d streams: %s\n", threads, src)
+		_, err = ifs.DownloadFileParallelResumable(src, "", dst, threads, true, callback)
+	case threads > 1:
+		log.Debugf("parallel download with %d streams: %s\n", threads, src)
+		_, err = ifs.DownloadFileParallel(src, "", dst, threads, true, callback)
+	default:
+		_, err = ifs.DownloadFileResumable(src, "", dst, true, callback)
+	}
+	return ppath.Checksum{}, err
+}
+
+// putFile puts the local file `src` of `size` bytes to the iRODS data object `dst`.
+//
+// A large file is uploaded with parallel streams, and verified by go-irodsclient which reads
+// the local file again.  Other files are uploaded with a single stream, and hashed with the
+// `DefaultChecksumAlgorithm` while being read.  The digest is returned for a streamed upload,
+// otherwise it is empty.  All uploads are rate-limited if the bandwidth is limited.
+func putFile(ctx context.Context, src, dst string, size int64, callback common.TrackerCallBack) (ppath.Checksum, error) {
+
+	threads := streams.Threads(size)
+	if threads == 1 {
+		return uploadStream(ctx, src, dst, size, ppath.DefaultChecksumAlgorithm, callback)
+	}
+
+	callback = bandwidth.Callback(ctx, callback)
+
+	if err := streams.Acquire(ctx, threads); err != nil {
+		return ppath.Checksum{}, err
+	}
+	defer streams.Release(threads)
+
+	log.Debugf("parallel upload with %d streams: %s\n", threads, src)
+	_, err := ctx.Value(dr.KeyFilesystem).(*fs.FileSystem).UploadFileParallel(src, dst, "", threads, false, true, true, callback)
+	return ppath.Checksum{}, err
+}
+
+// downloadStream gets the iRODS data object `src` to the local file `dst` with a single stream
+// at the rate of the bandwidth limit.  It returns the digest of the data computed with the
+// algorithm `alg`, or an empty checksum if the algorithm is not supported.
+func downloadStream(ctx context.Context, src, dst string, size int64, alg ppath.ChecksumAlgorithm, callback common.TrackerCallBack) (ppath.Checksum, error) {
+
+	if err := streams.Acquire(ctx, 1); err != nil {
+		return ppath.Checksum{}, err
+	}
+	defer streams.Release(1)
+
+	ifs := ctx.Value(dr.KeyFilesystem).(*fs.FileSystem)
+
+	fh, err := ifs.OpenFile(src, "", "r")
+	if err != nil {
+		return ppath.Checksum{}, err
+	}
+	defer fh.Close()
+
+	f, err := os.Create(dst)
+	if err != nil {
+		return ppath.Checksum{}, err
+	}
+
+	d := newDigest(alg)
+	if err := stream(d.Writer(f), bandwidth.Reader(ctx, fh), size, callback); err != nil {
+		f.Close()
+		return ppath.Checksum{}, err
+	}
+	return d.Sum(), f.Close()
+}
+
+// uploadStream puts the local file `src` to the iRODS data object `dst` with a single stream
+// at the rate of the bandwidth limit.  It returns the digest of the data computed with the
+// algorithm `alg`, or an empty checksum if the algorithm is not supported.
+func uploadStream(ctx context.Context, src, dst string, size int64, alg ppath.ChecksumAlgorithm, callback common.TrackerCallBack) (ppath.Checksum, error) {
+
+	f, err := os.Open(src)
+	if err != nil {
+		return ppath.Checksum{}, err
+	}
+	defer f.Close()
+
+	d := newDigest(alg)
+	if err := putStream(ctx, d.Reader(f), dst, size, callback); err != nil {
+		return ppath.Checksum{}, err
+	}
+	return d.Sum(), nil
+}
+
+// digest computes the checksum of the data streamed through it.  A digest of an unsupported
+// algorithm passes the data through, and its checksum is empty.
+type digest struct {
+	alg ppath.ChecksumAlgorithm
+	h   hash.Hash
+}
+
+func newDigest(alg ppath.ChecksumAlgorithm) *digest {
+	h, err := alg.NewHash()
+	if err != nil {
+		log.Debugf("%s\n", err)
+	}
+	return &digest{alg: alg, h: h}
+}
+
+// Reader returns the `io.Reader` that hashes the data read from `r`.
+func (d *digest) Reader(r io.Reader) io.Reader {
+	if d.h == nil {
+		return r
+	}
+	return io.TeeReader(r, d.h)
+}
+
+// Writer returns the `io.Writer` that hashes the data written to `w`.
+func (d *digest) Writer(w io.Writer) io.Writer {
+	if d.h == nil {
+		return w
+	}
+	return io.MultiWriter(w, d.h)
+}
+
+// Sum returns the checksum of the data streamed through the digest.
+func (d *digest) Sum() ppath.Checksum {
+	if d.h == nil {
+		return ppath.Checksum{}
+	}
+	return ppath.Checksum{Algorithm: d.alg, Value: d.h.Sum(nil)}
+}
+
+// putStream writes the data read from `r` to the iRODS data object `dst` with a single
+// stream, at the rate of the bandwidth limit.  An existing data object is overwritten.
+func putStream(ctx context.Context, r io.Reader, dst string, size int64, callback common.TrackerCallBack) error {
+
+	if err := streams.Acquire(ctx, 1); err != nil {
+		return err
+	}
+	defer streams.Release(1)
+
+	fh, err := ctx.Value(dr.KeyFilesystem).(*fs.FileSystem).CreateFile(dst, "", "w")
+	if err != nil {
+		return err
+	}
+
+	if err := stream(fh, bandwidth.Reader(ctx, r), size, callback); err != nil {
+		fh.Close()
+		return err
+	}
+	return fh.Close()
+}
+
+// stream copies data from `r` to `w`, and reports the accumulated number of bytes copied
+// to the `callback`.
+func stream(w io.Writer, r io.Reader, size int64, callback common.TrackerCallBack) error {
+	buf := make([]byte, common.ReadWriteBufferSize)
+
+	var done int64
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if _, werr := w.Write(buf[:n]); werr != nil {
+				return werr
+			}
+			done += int64(n)
+			if callback != nil {
+				callback(done, size)
+			}
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cyverse/go-irodsclient/fs"
+	irods_fs "github.com/cyverse/go-irodsclient/irods/fs"
+	"github.com/dccn-tg/dr-data-stager/pkg/dr"
+	ppath "github.com/dccn-tg/dr-data-stager/pkg/path"
+	log "github.com/dccn-tg/tg-toolset-golang/pkg/logger"
+)
+
+// irodsChecksum requests the checksum of the iRODS data object `path`.  If the checksum
+// is not available, it is computed and registered by iRODS.
+func irodsChecksum(ctx context.Context, path string) (ppath.Checksum, error) {
+	ifs := ctx.Value(dr.KeyFilesystem).(*fs.FileSystem)
+
+	conn, err := ifs.GetMetadataConnection()
+	if err != nil {
+		return ppath.Checksum{}, err
+	}
+	defer ifs.ReturnMetadataConnection(conn)
+
+	chksum, err := irods_fs.GetDataObjectChecksum(conn, path, "")
+	if err != nil {
+		return ppath.Checksum{}, err
+	}
+
+	return ppath.ParseIrodsChecksum(chksum.IRODSChecksumString)
+}
+
+// verifyChecksum compares the checksum of the local file `lpath` with the checksum of
+// the iRODS data object `ipath`.  The local checksum is computed with the algorithm of
+// the iRODS checksum, e.g. sha256.  It returns the checksum in the normalized form if
+// the two checksums are identical.
+func verifyChecksum(ctx context.Context, lpath, ipath string) (string, error) {
+
+	ichksum, err := irodsChecksum(ctx, ipath)
+	if err != nil {
+		return "", fmt.Errorf("cannot get checksum of %s: %w", ipath, err)
+	}
+
+	if ichksum.IsEmpty() {
+		return "", fmt.Errorf("no checksum available for %s", ipath)
+	}
+
+	lchksum, err := ppath.ComputeChecksum(lpath, ichksum.Algorithm)
+	if err != nil {
+		return "", fmt.Errorf("cannot compute checksum of %s: %w", lpath, err)
+	}
+
+	if !lchksum.Equal(ichksum) {
+		return "", fmt.Errorf("checksum mismatch: %s (%s) != %s (%s)", lpath, lchksum, ipath, ichksum)
+	}
+
+	return lchksum.String(), nil
+}
+
+// checkDigest compares the `digest` of the data streamed between the local file `lpath` and
+// the iRODS data object `ipath` with the iRODS checksum `ichksum`.  It is not an error if the
+// digest is empty or of a different algorithm, i.e. the data was not hashed while streaming.
+func checkDigest(lpath, ipath string, digest, ichksum ppath.Checksum) error {
+	if digest.IsEmpty() || digest.Algorithm != ichksum.Algorithm {
+		return nil
+	}
+
+	if !digest.Equal(ichksum) {
+		return fmt.Errorf("checksum mismatch: %s (%s) != %s (%s)", lpath, digest, ipath, ichksum)
+	}
+
+	return nil
+}
+
+// cacheDigest caches the `digest` of the data written to the local file `lpath`, so that
+// the file is not read again for its checksum.
+func cacheDigest(ctx context.Context, lpath string, digest ppath.Checksum) {
+	f, err := ppath.GetPathInfo(ctx, lpath)
+	if err == nil {
+		err = f.CacheChecksum(digest)
+	}
+	if err != nil {
+		log.Debugf("cannot cache checksum of %s: %s\n", lpath, err)
+	}
+}
+
+// verifyIrodsChecksum compares the checksums of the two iRODS data objects `isrc` and
+// `idst`.  It returns the checksum in the normalized form if the two checksums are
+// identical.
+func verifyIrodsChecksum(ctx context.Context, isrc, idst string) (string, error) {
+
+	schksum, err := irodsChecksum(ctx, isrc)
+	if err != nil {
+		return "", fmt.Errorf("cannot get checksum of %s: %w", isrc, err)
+	}
+
+	dchksum, err := irodsChecksum(ctx, idst)
+	if err != nil {
+		return "", fmt.Errorf("cannot get checksum of %s: %w", idst, err)
+	}
+
+	if !schksum.Equal(dchksum) {
+		return "", fmt.Errorf("checksum mismatch: %s (%s) != %s (%s)", isrc, schksum, idst, dchksum)
+	}
+
+	return dchksum.String(), nil
+}
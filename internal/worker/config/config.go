@@ -4,8 +4,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"time"
 
 	"github.com/dccn-tg/dr-data-stager/pkg/dr"
+	ppath "github.com/dccn-tg/dr-data-stager/pkg/path"
 	cfg "github.com/dccn-tg/tg-toolset-golang/pkg/config"
 	"github.com/spf13/viper"
 )
@@ -19,9 +22,78 @@ type Configuration struct {
 	Process ProcessConfiguration
 }
 
+// DefaultStateDir is the default top-level directory of the task state directories.
+const DefaultStateDir = "/var/lib/stager/state"
+
+// Default settings of transferring large files with parallel streams.
+const (
+	DefaultParallelThreshold int64 = 1 << 30
+	DefaultParallelThreads   int   = 4
+	DefaultMaxConnections    int   = 16
+)
+
+// Default settings of retrying a file transfer failed on a transient error.
+const (
+	DefaultFileRetries    int           = 3
+	DefaultFileRetryDelay time.Duration = 5 * time.Second
+)
+
 type ProcessConfiguration struct {
 	Concurrency int
 	Verbose     bool
+	// StateDir is the top-level directory in which the state (e.g. checkpoint journal)
+	// of each task is kept across retries.  It should be shared by all workers to allow
+	// a retry to be resumed on a different worker.
+	StateDir string
+	// ParallelThreshold is the file size in bytes above which a file is transferred
+	// with `ParallelThreads` parallel streams.
+	ParallelThreshold int64
+	// ParallelThreads is the number of parallel streams for transferring a large file;
+	// 1 for transferring all files with a single stream.
+	ParallelThreads int
+	// MaxConnections is the maximum number of iRODS connections a task uses for data
+	// transfer, shared by the concurrent file transfers of the task.
+	MaxConnections int
+	// BandwidthLimit is the bandwidth cap in bytes per second of the data transfer on
+	// the worker host, shared by the concurrent tasks; 0 for no limit.
+	BandwidthLimit int64
+	// FileRetries is the number of retries of a file transfer failed on a transient error,
+	// e.g. a broken iRODS connection; 0 for `DefaultFileRetries`, negative to disable retry.
+	FileRetries int
+	// FileRetryDelay is the initial delay in seconds between the retries of a file transfer,
+	// it is doubled on every retry; 0 for `DefaultFileRetryDelay`.
+	FileRetryDelay int
+	// FileMode is the permission mode, in octal (e.g. "0664"), of the files downloaded to
+	// the local filesystem.
+	FileMode string
+	// DirMode is the permission mode, in octal (e.g. "0775"), of the directories created
+	// on the local filesystem.
+	DirMode string
+}
+
+// FilePerm returns the permission mode of the files downloaded to the local filesystem.
+func (c ProcessConfiguration) FilePerm() os.FileMode {
+	if m, err := parseMode(c.FileMode); err == nil {
+		return m
+	}
+	return ppath.DefaultFileMode
+}
+
+// DirPerm returns the permission mode of the directories created on the local filesystem.
+func (c ProcessConfiguration) DirPerm() os.FileMode {
+	if m, err := parseMode(c.DirMode); err == nil {
+		return m
+	}
+	return ppath.DefaultDirMode
+}
+
+// parseMode parses the permission mode `s` in octal.
+func parseMode(s string) (os.FileMode, error) {
+	m, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || os.FileMode(m)&^os.ModePerm != 0 {
+		return 0, fmt.Errorf("invalid permission mode: %s", s)
+	}
+	return os.FileMode(m), nil
 }
 
 // LoadConfig reads configuration file `cpath` and returns the
@@ -50,5 +122,39 @@ func LoadConfig(cpath string) (Configuration, error) {
 		return conf, fmt.Errorf("unable to decode into struct, %v", err)
 	}
 
+	if conf.Process.StateDir == "" {
+		conf.Process.StateDir = DefaultStateDir
+	}
+
+	if conf.Process.ParallelThreshold <= 0 {
+		conf.Process.ParallelThreshold = DefaultParallelThreshold
+	}
+
+	if conf.Process.ParallelThreads <= 0 {
+		conf.Process.ParallelThreads = DefaultParallelThreads
+	}
+
+	if conf.Process.MaxConnections <= 0 {
+		conf.Process.MaxConnections = DefaultMaxConnections
+	}
+
+	if conf.Process.FileRetries == 0 {
+		conf.Process.FileRetries = DefaultFileRetries
+	}
+
+	if conf.Process.FileRetries < 0 {
+		conf.Process.FileRetries = 0
+	}
+
+	if conf.Process.FileRetryDelay <= 0 {
+		conf.Process.FileRetryDelay = int(DefaultFileRetryDelay / time.Second)
+	}
+
+	for _, m := range []string{conf.Process.FileMode, conf.Process.DirMode} {
+		if _, err := parseMode(m); m != "" && err != nil {
+			return conf, err
+		}
+	}
+
 	return conf, nil
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/dccn-tg/dr-data-stager/internal/worker/config"
 	"github.com/dccn-tg/dr-data-stager/internal/worker/middleware"
 	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
 
 	"github.com/dccn-tg/dr-data-stager/pkg/tasks"
 	log "github.com/dccn-tg/tg-toolset-golang/pkg/logger"
@@ -77,6 +78,11 @@ func main() {
 	// inspector
 	inspector := asynq.NewInspector(redisOpts)
 
+	// redis client for storing transfer manifests
+	rdbOpts, _ := redis.ParseURL(*redisURL)
+	rdb := redis.NewClient(rdbOpts)
+	defer rdb.Close()
+
 	srv := asynq.NewServer(
 		redisOpts,
 		asynq.Config{
@@ -93,7 +99,9 @@ func main() {
 	// mux maps a type to a handler
 	mux := asynq.NewServeMux()
 	mux.Use(middleware.Notifier(inspector, cfg))
-	mux.Handle(tasks.TypeStager, tasks.NewStager(cfg))
+	stager := tasks.NewStager(cfg, rdb)
+	mux.Handle(tasks.TypeStager, stager)
+	mux.Handle(tasks.TypePlan, stager)
 	// ...register other handlers...
 
 	if err := srv.Run(mux); err != nil {
@@ -29,6 +29,7 @@ type nmode int
 const (
 	nFailed nmode = iota
 	nCompleted
+	nCompletedWithFailures
 )
 
 func (m nmode) String() string {
@@ -37,6 +38,8 @@ func (m nmode) String() string {
 		return "failed"
 	case nCompleted:
 		return "completed"
+	case nCompletedWithFailures:
+		return "completed with failures"
 	}
 	return "unknown"
 }
@@ -106,10 +109,19 @@ func Notifier(inspector *asynq.Inspector, cfg config.Configuration) func(asynq.H
 					log.Errorf("cannot get task %s: %s\n", id, err)
 					break
 				} else {
-					sendEmailNotification(&client, tinfo, nCompleted)
+					sendEmailNotification(&client, tinfo, completedMode(tinfo))
+				}
+			case errors.Is(err, asynq.SkipRetry):
+				log.Debugf("job failed without retry, notifying job owner and admin: %+v\n", client)
+				id, _ := asynq.GetTaskID(ctx)
+				q, _ := asynq.GetQueueName(ctx)
+				if tinfo, ierr := inspector.GetTaskInfo(q, id); ierr != nil {
+					log.Errorf("cannot get task %s: %s\n", id, ierr)
+				} else {
+					// the error of this attempt is not yet recorded in the task info.
+					tinfo.LastErr = err.Error()
+					sendEmailNotification(&client, tinfo, nFailed, cfg.Admins...)
 				}
-			case err == asynq.SkipRetry:
-				log.Debugf("job retry skipped")
 			case errors.Is(err, context.Canceled):
 				log.Debugf("job canceled")
 
@@ -147,6 +159,19 @@ func Notifier(inspector *asynq.Inspector, cfg config.Configuration) func(asynq.H
 	}
 }
 
+// completedMode determines the notification mode of a completed task based on
+// the number of failed files in the task result.
+func completedMode(tinfo *asynq.TaskInfo) nmode {
+	var rslt tasks.StagerTaskResult
+	if err := json.Unmarshal(tinfo.Result, &rslt); err != nil {
+		return nCompleted
+	}
+	if rslt.Progress.Failed > 0 {
+		return nCompletedWithFailures
+	}
+	return nCompleted
+}
+
 func sendEmailNotification(client *stagerMailer, tinfo *asynq.TaskInfo, nt nmode, cc ...string) {
 
 	var p tasks.StagerPayload
@@ -173,6 +198,8 @@ func sendEmailNotification(client *stagerMailer, tinfo *asynq.TaskInfo, nt nmode
 		}
 	case nCompleted:
 		subject = fmt.Sprintf("[OK] stager job %s completed", idparts[len(idparts)-1])
+	case nCompletedWithFailures:
+		subject = fmt.Sprintf("[WARNING] stager job %s completed with failures", idparts[len(idparts)-1])
 	}
 
 	if len(recipients) == 0 {
@@ -209,9 +236,9 @@ func composeMailBody(tinfo *asynq.TaskInfo, payload tasks.StagerPayload, mode nm
 		tmsg = templateNotificationFailed
 		ntStr = "failed"
 		tlastfailed = time.Now().Truncate(time.Second)
-	case nCompleted:
+	case nCompleted, nCompletedWithFailures:
 		tmsg = templateNotificationCompleted
-		ntStr = "completed"
+		ntStr = mode.String()
 		tcompleted = time.Now().Truncate(time.Second)
 	}
 
@@ -68,6 +68,46 @@ const templateNotificationCompleted string = `
 				<th>progress</th>
 				<td>{{ .Result.Progress.Processed }} / {{ .Result.Progress.Total }}</td>
 			</tr>
+			{{- if .Result.Progress.Failed }}
+			<tr>
+				<th>failed</th>
+				<td>{{ .Result.Progress.Failed }}</td>
+			</tr>
+			{{- end }}
+			{{- if .Result.Deleted }}
+			<tr>
+				<th>deleted</th>
+				<td>{{ .Result.Deleted }}</td>
+			</tr>
+			{{- end }}
+			{{- with .Result.Plan }}
+			<tr>
+				<th>planned copies</th>
+				<td>{{ .Copy }} new, {{ .Overwrite }} overwritten ({{ .Bytes }} bytes)</td>
+			</tr>
+			<tr>
+				<th>planned skips</th>
+				<td>{{ .Skip }}</td>
+			</tr>
+			{{- if .Delete }}
+			<tr>
+				<th>planned deletions</th>
+				<td>{{ .Delete }}</td>
+			</tr>
+			{{- end }}
+			{{- end }}
+			{{- if .Result.SkippedLinks }}
+			<tr>
+				<th>skipped symlinks</th>
+				<td>{{ .Result.SkippedLinks }}</td>
+			</tr>
+			{{- end }}
+			{{- with .Result.Conflicts }}{{ if .Total }}
+			<tr>
+				<th>existing files</th>
+				<td>{{ .Overwritten }} overwritten, {{ .Skipped }} skipped, {{ .Renamed }} renamed, {{ .Failed }} failed</td>
+			</tr>
+			{{- end }}{{ end }}
 		</table>
 	</div>
 </html>`
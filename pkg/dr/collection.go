@@ -0,0 +1,46 @@
+package dr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cyverse/go-irodsclient/irods/types"
+)
+
+// Metadata attributes of a DR collection holding the quota of the collection and the size
+// of the data in it, in bytes.  They are maintained by the DR, see the collection metadata
+// of the dr-gateway (`github.com/dccn-tg/dr-gateway/pkg/dr`).
+const (
+	AttrQuotaInBytes = "quotaInBytes"
+	AttrSizeInBytes  = "sizeInBytes"
+)
+
+// AvailableQuota returns the quota of the DR collection with the metadata `metas` minus the
+// size of the data in the collection, or 0 if the size exceeds the quota.  The bool value is
+// false if the collection has no quota, i.e. it is not a DR collection.
+func AvailableQuota(metas []*types.IRODSMeta) (int64, bool, error) {
+
+	var quota, size int64 = -1, 0
+	for _, m := range metas {
+		var err error
+		switch m.Name {
+		case AttrQuotaInBytes:
+			quota, err = strconv.ParseInt(strings.TrimSpace(m.Value), 10, 64)
+		case AttrSizeInBytes:
+			size, err = strconv.ParseInt(strings.TrimSpace(m.Value), 10, 64)
+		}
+		if err != nil {
+			return 0, false, fmt.Errorf("invalid %s: %s", m.Name, m.Value)
+		}
+	}
+
+	switch {
+	case quota < 0:
+		return 0, false, nil
+	case size > quota:
+		return 0, true, nil
+	default:
+		return quota - size, true, nil
+	}
+}
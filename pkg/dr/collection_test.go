@@ -0,0 +1,39 @@
+package dr
+
+import (
+	"testing"
+
+	"github.com/cyverse/go-irodsclient/irods/types"
+)
+
+func TestAvailableQuota(t *testing.T) {
+
+	meta := func(name, value string) *types.IRODSMeta {
+		return &types.IRODSMeta{Name: name, Value: value}
+	}
+
+	cases := []struct {
+		name  string
+		metas []*types.IRODSMeta
+		avail int64
+		ok    bool
+		err   bool
+	}{
+		{"quota and size", []*types.IRODSMeta{meta("collectionType", "DAC"), meta(AttrQuotaInBytes, "1000"), meta(AttrSizeInBytes, " 400 ")}, 600, true, false},
+		{"quota without size", []*types.IRODSMeta{meta(AttrQuotaInBytes, "1000")}, 1000, true, false},
+		{"size exceeds quota", []*types.IRODSMeta{meta(AttrQuotaInBytes, "1000"), meta(AttrSizeInBytes, "1200")}, 0, true, false},
+		{"no quota", []*types.IRODSMeta{meta(AttrSizeInBytes, "400")}, 0, false, false},
+		{"invalid quota", []*types.IRODSMeta{meta(AttrQuotaInBytes, "1k")}, 0, false, true},
+	}
+
+	for _, c := range cases {
+		avail, ok, err := AvailableQuota(c.metas)
+		if (err != nil) != c.err {
+			t.Errorf("%s: unexpected error: %v", c.name, err)
+			continue
+		}
+		if avail != c.avail || ok != c.ok {
+			t.Errorf("%s: expect (%d, %t), got (%d, %t)", c.name, c.avail, c.ok, avail, ok)
+		}
+	}
+}
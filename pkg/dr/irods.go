@@ -113,6 +113,20 @@ func NewProxyAccount(config Config, user string) (*types.IRODSAccount, error) {
 	return account, nil
 }
 
+// NewFileSystemWithMaxConnections creates a iRODS filesystem which uses at most `max`
+// connections for data transfer.
+func NewFileSystemWithMaxConnections(appName string, config Config, max int) (*fs.FileSystem, error) {
+	acct, err := NewAccount(config)
+	if err != nil {
+		return nil, err
+	}
+
+	fsconfig := fs.NewFileSystemConfigWithDefault(appName)
+	fsconfig.ConnectionMax = max
+
+	return fs.NewFileSystem(acct, fsconfig)
+}
+
 func NewFileSystem(appName string, config Config) (*fs.FileSystem, error) {
 	acct, err := NewAccount(config)
 	if err != nil {
@@ -2,6 +2,14 @@ package errors
 
 import "fmt"
 
+// ExitCodePartialSuccess is the exit code of s-isync when the transfer is completed
+// but one or more files failed to be transferred.
+const ExitCodePartialSuccess = 3
+
+// ExitCodeInsufficientSpace is the exit code of s-isync when the destination doesn't have
+// enough space for the data to be transferred.
+const ExitCodeInsufficientSpace = 4
+
 func ToIsyncError(ec int, msg string) *IsyncError {
 	if ec == 0 {
 		return nil
@@ -29,6 +37,10 @@ func (e *IsyncError) Error() string {
 	switch e.ec {
 	case 1:
 		prefix = "general error"
+	case ExitCodePartialSuccess:
+		prefix = "partial success"
+	case ExitCodeInsufficientSpace:
+		prefix = "insufficient space"
 	case 128:
 		prefix = "invalid argument"
 	case 130:
@@ -0,0 +1,171 @@
+// Package event defines the structured events emitted by `s-isync` to report
+// its activities to the worker.
+//
+// Events are written as JSON lines on the stdout of `s-isync`.  Each event carries
+// the protocol `Version` so that the worker can detect an incompatible `s-isync`.
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Version is the version of the event protocol.
+const Version = 1
+
+// Type is the type of the event.
+type Type string
+
+// A list of event types.
+const (
+	// TypePhase indicates that s-isync enters a new phase given by the `Phase` field.
+	TypePhase Type = "phase"
+	// TypeHeartbeat is emitted periodically to indicate that s-isync is alive, e.g.
+	// during a long-running scan.
+	TypeHeartbeat Type = "heartbeat"
+	// TypeProgress reports the progress of the transfer.
+	TypeProgress Type = "progress"
+	// TypeFileStarted indicates that the transfer of a file is started.
+	TypeFileStarted Type = "file_started"
+	// TypeFileDone indicates that a file is transferred.
+	TypeFileDone Type = "file_done"
+	// TypeFileSkipped indicates that a file is skipped as it is already at the destination.
+	TypeFileSkipped Type = "file_skipped"
+	// TypeFileError indicates that a file failed to be transferred.
+	TypeFileError Type = "file_error"
+	// TypeFilePlanned reports the action given by the `Action` field planned on a file in
+	// the dry-run mode.
+	TypeFilePlanned Type = "file_planned"
+	// TypeLinkSkipped indicates that a symbolic link is skipped by the symlink policy.
+	TypeLinkSkipped Type = "link_skipped"
+	// TypeFileDeleted indicates that a file is removed from the destination in the mirror mode.
+	TypeFileDeleted Type = "file_deleted"
+	// TypeSummary is the last event of s-isync.
+	TypeSummary Type = "summary"
+)
+
+// A list of phases of s-isync.
+const (
+	// PhaseScanning is the scan of the source, while the files found so far are transferred.
+	// The total of the progress is provisional in this phase.
+	PhaseScanning = "scanning"
+	// PhaseTransferring is the transfer of the remaining files after the scan is complete.
+	PhaseTransferring = "transferring"
+	// PhaseVerifying is the verification of the transferred file given by the `File` and
+	// `DstFile` fields.  The files are verified along with their transfer, the phase is
+	// therefore reported per file, and ends with the outcome of the file.
+	PhaseVerifying = "verifying"
+	// PhaseDeleting is the removal of the extraneous files at the destination in the mirror mode.
+	PhaseDeleting = "deleting"
+)
+
+// Progress is the progress of the transfer.
+type Progress struct {
+	Total      int64 `json:"total"`
+	Success    int64 `json:"success"`
+	Failure    int64 `json:"failure"`
+	TotalBytes int64 `json:"totalBytes"`
+	DoneBytes  int64 `json:"doneBytes"`
+	// Provisional indicates that `Total` and `TotalBytes` are still growing as
+	// the scan of the source is in progress.
+	Provisional bool `json:"provisional,omitempty"`
+}
+
+// Event is the data structure of an event.
+type Event struct {
+	// version of the event protocol
+	Version int `json:"v"`
+
+	// event type
+	Type Type `json:"type"`
+
+	// unix timestamp of the event
+	Time int64 `json:"time"`
+
+	// phase s-isync enters, for `TypePhase` event
+	Phase string `json:"phase,omitempty"`
+
+	// source and destination path, and size of the file, for `TypeFile*` events and the
+	// `PhaseVerifying` phase.  For `TypeFileDeleted` event, `File` is the path removed from
+	// the destination.
+	File    string `json:"file,omitempty"`
+	DstFile string `json:"dstFile,omitempty"`
+	Size    int64  `json:"size,omitempty"`
+
+	// action planned on the file, for `TypeFilePlanned` event
+	Action string `json:"action,omitempty"`
+
+	// conflict policy applied to the file existing at the destination, for `TypeFileDone`,
+	// `TypeFileSkipped`, `TypeFileError` and `TypeFilePlanned` events
+	Conflict string `json:"conflict,omitempty"`
+
+	// error message, for `TypeFileError` and `TypeSummary` events
+	Error string `json:"error,omitempty"`
+
+	// progress of the transfer at the time of the event
+	Progress *Progress `json:"progress,omitempty"`
+}
+
+// Parse decodes an event from a JSON line.  An error is returned if the event is
+// of an unsupported protocol version.
+func Parse(line []byte) (*Event, error) {
+	var e Event
+	if err := json.Unmarshal(line, &e); err != nil {
+		return nil, err
+	}
+
+	if e.Version < 1 || e.Version > Version {
+		return nil, fmt.Errorf("unsupported event version: %d", e.Version)
+	}
+
+	return &e, nil
+}
+
+// Writer writes events as JSON lines to the underlying `io.Writer`.  It is safe
+// for concurrent use.
+type Writer struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewWriter creates a Writer writing events to `w`.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{enc: json.NewEncoder(w)}
+}
+
+// Emit writes the event `e`, with the protocol version and the current time.
+func (w *Writer) Emit(e Event) error {
+	e.Version = Version
+	e.Time = time.Now().Unix()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.enc.Encode(e)
+}
+
+// Heartbeat emits a `TypeHeartbeat` event every `interval` until the returned
+// function is called or the `ctx` is cancelled.
+func (w *Writer) Heartbeat(ctx context.Context, interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				w.Emit(Event{Type: TypeHeartbeat})
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
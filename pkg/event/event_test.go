@@ -0,0 +1,54 @@
+package event
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+)
+
+func TestEmitAndParse(t *testing.T) {
+
+	var buf bytes.Buffer
+
+	w := NewWriter(&buf)
+	w.Emit(Event{Type: TypePhase, Phase: PhaseScanning})
+	w.Emit(Event{
+		Type:     TypeFileDone,
+		File:     "/project/3010000.01/raw/sub-01.ds",
+		Size:     1024,
+		Progress: &Progress{Total: 3, Success: 1, TotalBytes: 3072, DoneBytes: 1024},
+	})
+
+	scanner := bufio.NewScanner(&buf)
+
+	var events []*Event
+	for scanner.Scan() {
+		e, err := Parse(scanner.Bytes())
+		if err != nil {
+			t.Fatalf("%s\n", err)
+		}
+		events = append(events, e)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("unexpected number of events: %d", len(events))
+	}
+
+	if events[0].Type != TypePhase || events[0].Phase != PhaseScanning {
+		t.Errorf("unexpected phase event: %+v", events[0])
+	}
+
+	if events[1].Progress == nil || events[1].Progress.DoneBytes != 1024 {
+		t.Errorf("unexpected file event: %+v", events[1])
+	}
+}
+
+func TestParseUnsupportedVersion(t *testing.T) {
+	if _, err := Parse([]byte(`{"v":99,"type":"heartbeat"}`)); err == nil {
+		t.Errorf("expect error on unsupported version")
+	}
+
+	if _, err := Parse([]byte(`{"type":"heartbeat"}`)); err == nil {
+		t.Errorf("expect error on missing version")
+	}
+}
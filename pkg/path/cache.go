@@ -0,0 +1,161 @@
+package path
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+
+	log "github.com/dccn-tg/tg-toolset-golang/pkg/logger"
+	"golang.org/x/sys/unix"
+)
+
+// checksumCacheAttr is the prefix of the user extended attribute in which the checksum of a
+// local file is cached.  It is followed by the name of the checksum algorithm.
+const checksumCacheAttr = "user.stager.checksum."
+
+// checksumCacheEntry is the checksum cached in the extended attribute of a local file,
+// together with the properties of the file when the checksum was computed.  The entry is
+// stale if any of the properties has changed since, e.g. the file is modified or replaced,
+// or the attribute is copied along with the file.
+type checksumCacheEntry struct {
+	Dev     uint64 `json:"dev"`
+	Ino     uint64 `json:"ino"`
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"mtime"`
+	Value   string `json:"value"`
+}
+
+// newChecksumCacheEntry returns the cache entry with the properties of the file `fi`.
+func newChecksumCacheEntry(fi os.FileInfo) (checksumCacheEntry, error) {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return checksumCacheEntry{}, fmt.Errorf("cannot get inode of %s", fi.Name())
+	}
+	return checksumCacheEntry{
+		Dev:     uint64(st.Dev),
+		Ino:     uint64(st.Ino),
+		Size:    fi.Size(),
+		ModTime: fi.ModTime().UnixNano(),
+	}, nil
+}
+
+// sameFile checks whether the entry `o` is for the same version of the file as `e`.
+func (e checksumCacheEntry) sameFile(o checksumCacheEntry) bool {
+	return e.Dev == o.Dev && e.Ino == o.Ino && e.Size == o.Size && e.ModTime == o.ModTime
+}
+
+// CachedChecksum returns the checksum of the local file `path` computed with the
+// algorithm `alg`, using the checksum cached in the extended attribute of the file if it
+// is not stale.  The computed checksum is cached for the next call.
+//
+// Failing to read or write the cache, e.g. the filesystem doesn't support user extended
+// attributes or the file is not writable, is not an error; the checksum is then computed
+// every time.
+func CachedChecksum(path string, alg ChecksumAlgorithm) (Checksum, error) {
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return Checksum{}, err
+	}
+
+	key, err := newChecksumCacheEntry(fi)
+	if err != nil {
+		return ComputeChecksum(path, alg)
+	}
+
+	attr := checksumCacheAttr + string(alg)
+
+	if c, err := readChecksumCache(path, attr, key); err == nil {
+		return Checksum{Algorithm: alg, Value: c}, nil
+	} else if !errors.Is(err, unix.ENODATA) {
+		log.Debugf("ignore checksum cache of %s: %s\n", path, err)
+	}
+
+	c, err := ComputeChecksum(path, alg)
+	if err != nil {
+		return c, err
+	}
+
+	// only cache the checksum if the file was not changed while being read
+	if fi, err = os.Stat(path); err != nil {
+		return c, nil
+	}
+	if now, err := newChecksumCacheEntry(fi); err != nil || !now.sameFile(key) {
+		return c, nil
+	}
+
+	key.Value = hex.EncodeToString(c.Value)
+	if err := writeChecksumCache(path, attr, key); err != nil {
+		log.Debugf("cannot cache checksum of %s: %s\n", path, err)
+	}
+
+	return c, nil
+}
+
+// readChecksumCache reads the checksum cached in the extended attribute `attr` of `path`.
+// An error is returned if there is no cache, or the cache is stale as compared to `key`.
+func readChecksumCache(path, attr string, key checksumCacheEntry) ([]byte, error) {
+
+	buf := make([]byte, 512)
+	n, err := unix.Getxattr(path, attr, buf)
+	if err != nil {
+		return nil, err
+	}
+
+	var e checksumCacheEntry
+	if err := json.Unmarshal(buf[:n], &e); err != nil {
+		return nil, err
+	}
+
+	if !e.sameFile(key) {
+		return nil, fmt.Errorf("stale cache entry")
+	}
+
+	return hex.DecodeString(e.Value)
+}
+
+// writeChecksumCache writes the cache entry `e` into the extended attribute `attr` of `path`.
+func writeChecksumCache(path, attr string, e checksumCacheEntry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return unix.Setxattr(path, attr, data, 0)
+}
+
+// CacheChecksum caches the checksum `c` of the local file `p`, e.g. the digest computed while
+// the file was transferred, so that it is not computed again by `CachedChecksum`.  The checksum
+// is not cached if the file has been modified since `p` was resolved.
+func (p *PathInfo) CacheChecksum(c Checksum) error {
+
+	if p.Type != TypeFileSystem || c.IsEmpty() {
+		return nil
+	}
+
+	fi, err := os.Stat(p.Path)
+	if err != nil {
+		return err
+	}
+
+	if fi.Size() != p.Size || !fi.ModTime().Equal(p.ModTime) {
+		return fmt.Errorf("%s modified since %s", p.Path, p.ModTime)
+	}
+
+	e, err := newChecksumCacheEntry(fi)
+	if err != nil {
+		return err
+	}
+	e.Value = hex.EncodeToString(c.Value)
+
+	if err := writeChecksumCache(p.Path, checksumCacheAttr+string(c.Algorithm), e); err != nil {
+		return err
+	}
+
+	if p.checksum.IsEmpty() || p.checksum.Algorithm == c.Algorithm {
+		p.checksum = c
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package path
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestCachedChecksum(t *testing.T) {
+
+	fpath := filepath.Join(t.TempDir(), "data")
+	if err := os.WriteFile(fpath, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	attr := checksumCacheAttr + string(ChecksumSHA256)
+	if err := unix.Setxattr(fpath, attr, []byte("{}"), 0); err != nil {
+		t.Skipf("user extended attributes not supported: %s", err)
+	}
+
+	expected, err := ComputeChecksum(fpath, ChecksumSHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the invalid entry is replaced by the computed checksum
+	c, err := CachedChecksum(fpath, ChecksumSHA256)
+	if err != nil || !c.Equal(expected) {
+		t.Fatalf("expected %s, got %s (%v)", expected, c, err)
+	}
+
+	// the cached checksum is returned as long as the file is not changed
+	key, err := os.Stat(fpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, _ := newChecksumCacheEntry(key)
+	e.Value = "00"
+	if err := writeChecksumCache(fpath, attr, e); err != nil {
+		t.Fatal(err)
+	}
+	if c, err := CachedChecksum(fpath, ChecksumSHA256); err != nil || c.String() != "sha256:00" {
+		t.Errorf("expected cached checksum sha256:00, got %s (%v)", c, err)
+	}
+
+	// the entry is stale after the file is modified
+	mtime := time.Now().Add(time.Hour)
+	if err := os.Chtimes(fpath, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	if c, err := CachedChecksum(fpath, ChecksumSHA256); err != nil || !c.Equal(expected) {
+		t.Errorf("expected %s, got %s (%v)", expected, c, err)
+	}
+}
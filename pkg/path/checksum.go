@@ -0,0 +1,169 @@
+package path
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/cyverse/go-irodsclient/irods/types"
+)
+
+// ChecksumAlgorithm is the algorithm of a checksum.
+type ChecksumAlgorithm string
+
+// A list of supported checksum algorithms.
+const (
+	ChecksumUnknown ChecksumAlgorithm = ""
+	ChecksumMD5     ChecksumAlgorithm = "md5"
+	ChecksumSHA1    ChecksumAlgorithm = "sha1"
+	ChecksumSHA256  ChecksumAlgorithm = "sha256"
+	ChecksumSHA512  ChecksumAlgorithm = "sha512"
+)
+
+// DefaultChecksumAlgorithm is the algorithm used when there is no algorithm imposed
+// by iRODS, e.g. comparing two local files.
+const DefaultChecksumAlgorithm = ChecksumSHA256
+
+// NewHash returns the `hash.Hash` implementing the algorithm.
+func (a ChecksumAlgorithm) NewHash() (hash.Hash, error) {
+	switch a {
+	case ChecksumMD5:
+		return md5.New(), nil
+	case ChecksumSHA1:
+		return sha1.New(), nil
+	case ChecksumSHA256:
+		return sha256.New(), nil
+	case ChecksumSHA512:
+		return sha512.New(), nil
+	default:
+		return nil, fmt.Errorf("unsupported checksum algorithm: %q", string(a))
+	}
+}
+
+// Checksum is the digest of a file together with the algorithm of the digest.
+type Checksum struct {
+	Algorithm ChecksumAlgorithm
+	Value     []byte
+}
+
+// IsEmpty checks whether the checksum has a value.
+func (c Checksum) IsEmpty() bool {
+	return len(c.Value) == 0
+}
+
+// Equal compares two checksums in the normalized form.  Checksums of different
+// algorithms, or empty checksums, are never equal.
+func (c Checksum) Equal(o Checksum) bool {
+	if c.IsEmpty() || o.IsEmpty() || c.Algorithm == ChecksumUnknown {
+		return false
+	}
+	return c.Algorithm == o.Algorithm && bytes.Equal(c.Value, o.Value)
+}
+
+// String returns the normalized form of the checksum, i.e. `<algorithm>:<hex digest>`.
+// An empty checksum results in an empty string.
+func (c Checksum) String() string {
+	if c.IsEmpty() {
+		return ""
+	}
+	return fmt.Sprintf("%s:%x", c.Algorithm, c.Value)
+}
+
+// ParseIrodsChecksum parses the checksum string stored in iRODS.  The algorithm is
+// determined by the prefix of the string, i.e.
+//
+//   - `sha2:<base64>` for sha256,
+//   - `sha512:<base64>` for sha512,
+//   - `sha1:<base64>` for sha1,
+//   - `<hex>` without prefix for md5.
+//
+// An empty string results in an empty checksum.
+func ParseIrodsChecksum(s string) (Checksum, error) {
+
+	if s == "" {
+		return Checksum{}, nil
+	}
+
+	var (
+		alg    ChecksumAlgorithm
+		digest string
+	)
+
+	prefix, value, found := strings.Cut(s, ":")
+
+	switch {
+	case !found:
+		alg, digest = ChecksumMD5, s
+		v, err := hex.DecodeString(digest)
+		if err != nil {
+			return Checksum{}, fmt.Errorf("invalid md5 checksum %s: %w", s, err)
+		}
+		return Checksum{Algorithm: alg, Value: v}, nil
+	case prefix == "sha2":
+		alg, digest = ChecksumSHA256, value
+	case prefix == "sha512":
+		alg, digest = ChecksumSHA512, value
+	case prefix == "sha1":
+		alg, digest = ChecksumSHA1, value
+	default:
+		return Checksum{}, fmt.Errorf("unsupported checksum algorithm: %s", prefix)
+	}
+
+	v, err := base64.StdEncoding.DecodeString(digest)
+	if err != nil {
+		return Checksum{}, fmt.Errorf("invalid %s checksum %s: %w", alg, s, err)
+	}
+
+	return Checksum{Algorithm: alg, Value: v}, nil
+}
+
+// NewIrodsChecksum converts the decoded checksum `value` and `algorithm` provided
+// by the go-irodsclient into a Checksum.
+func NewIrodsChecksum(algorithm types.ChecksumAlgorithm, value []byte) Checksum {
+
+	alg := ChecksumUnknown
+
+	switch algorithm {
+	case types.ChecksumAlgorithmMD5:
+		alg = ChecksumMD5
+	case types.ChecksumAlgorithmSHA1:
+		alg = ChecksumSHA1
+	case types.ChecksumAlgorithmSHA256:
+		alg = ChecksumSHA256
+	case types.ChecksumAlgorithmSHA512:
+		alg = ChecksumSHA512
+	}
+
+	return Checksum{Algorithm: alg, Value: value}
+}
+
+// ComputeChecksum computes the checksum of the local file `path` using the
+// algorithm `alg`.
+func ComputeChecksum(path string, alg ChecksumAlgorithm) (Checksum, error) {
+
+	h, err := alg.NewHash()
+	if err != nil {
+		return Checksum{}, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return Checksum{}, err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(h, f); err != nil {
+		return Checksum{}, err
+	}
+
+	return Checksum{Algorithm: alg, Value: h.Sum(nil)}, nil
+}
@@ -0,0 +1,71 @@
+package path
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseIrodsChecksum(t *testing.T) {
+
+	// checksums of the string "hello world\n"
+	cases := []struct {
+		input string
+		alg   ChecksumAlgorithm
+		norm  string
+	}{
+		{"6f5902ac237024bdd0c176cb93063dc4", ChecksumMD5, "md5:6f5902ac237024bdd0c176cb93063dc4"},
+		{"sha2:qUiQTy8PR5uPgZdpSzAYSw0u0cHNKh7A+4XSmaGSpEc=", ChecksumSHA256, "sha256:a948904f2f0f479b8f8197694b30184b0d2ed1c1cd2a1ec0fb85d299a192a447"},
+		{"sha1:IlljY7PeQLBvmB+4XYIxLowO1RE=", ChecksumSHA1, "sha1:22596363b3de40b06f981fb85d82312e8c0ed511"},
+		{"", ChecksumUnknown, ""},
+	}
+
+	for _, c := range cases {
+		chksum, err := ParseIrodsChecksum(c.input)
+		if err != nil {
+			t.Errorf("%s: %s\n", c.input, err)
+			continue
+		}
+		if chksum.Algorithm != c.alg {
+			t.Errorf("%s: unexpected algorithm %s", c.input, chksum.Algorithm)
+		}
+		if chksum.String() != c.norm {
+			t.Errorf("%s: unexpected normalized checksum %s", c.input, chksum)
+		}
+	}
+
+	if _, err := ParseIrodsChecksum("adler32:1234"); err == nil {
+		t.Errorf("expect error on unsupported algorithm")
+	}
+}
+
+func TestComputeChecksum(t *testing.T) {
+
+	fpath := filepath.Join(t.TempDir(), "hello.txt")
+	if err := os.WriteFile(fpath, []byte("hello world\n"), 0644); err != nil {
+		t.Fatalf("%s\n", err)
+	}
+
+	for _, s := range []string{
+		"6f5902ac237024bdd0c176cb93063dc4",
+		"sha2:qUiQTy8PR5uPgZdpSzAYSw0u0cHNKh7A+4XSmaGSpEc=",
+	} {
+		ichksum, _ := ParseIrodsChecksum(s)
+
+		lchksum, err := ComputeChecksum(fpath, ichksum.Algorithm)
+		if err != nil {
+			t.Fatalf("%s\n", err)
+		}
+
+		if !lchksum.Equal(ichksum) {
+			t.Errorf("checksum mismatch: %s != %s", lchksum, ichksum)
+		}
+	}
+
+	// checksums of different algorithms are never equal
+	md5sum, _ := ComputeChecksum(fpath, ChecksumMD5)
+	sha2sum, _ := ComputeChecksum(fpath, ChecksumSHA256)
+	if md5sum.Equal(sha2sum) {
+		t.Errorf("checksums of different algorithms are equal")
+	}
+}
@@ -0,0 +1,122 @@
+package path
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/cyverse/go-irodsclient/fs"
+	"github.com/dccn-tg/dr-data-stager/pkg/dr"
+	log "github.com/dccn-tg/tg-toolset-golang/pkg/logger"
+)
+
+// Metadata attributes with which the properties of the local file are kept on the uploaded
+// iRODS data object.
+const (
+	// ModTimeAttribute is the last modification time of the original file, in seconds
+	// since epoch.
+	ModTimeAttribute = "stager.mtime"
+	// ModeAttribute is the permission mode of the original file, in octal.
+	ModeAttribute = "stager.mode"
+)
+
+// CompareStrategy defines how a file at the destination is determined to be identical to
+// the source file, in which case the transfer of the file is skipped.
+type CompareStrategy string
+
+const (
+	// CompareChecksum compares the size and the checksum of the files.
+	CompareChecksum CompareStrategy = "checksum"
+	// CompareSizeModTime compares the size and the modification time of the files.  The
+	// modification time of an iRODS data object is the one of the original file recorded
+	// as `ModTimeAttribute`, or the modify time of the data object in iRODS.
+	CompareSizeModTime CompareStrategy = "size-mtime"
+	// CompareAlways considers no file identical, all files are transferred.
+	CompareAlways CompareStrategy = "always"
+)
+
+// ParseCompareStrategy converts the string `s` into a CompareStrategy.  An empty string
+// is the `CompareChecksum` strategy.
+func ParseCompareStrategy(s string) (CompareStrategy, error) {
+	switch c := CompareStrategy(s); c {
+	case "":
+		return CompareChecksum, nil
+	case CompareChecksum, CompareSizeModTime, CompareAlways:
+		return c, nil
+	default:
+		return "", fmt.Errorf("unknown compare strategy %q", s)
+	}
+}
+
+// NewComparator returns the implementation of the Comparator for the `strategy`.
+func NewComparator(strategy CompareStrategy) Comparator {
+	switch strategy {
+	case CompareSizeModTime:
+		return SizeModTimeComparator{}
+	case CompareAlways:
+		return AlwaysComparator{}
+	default:
+		return ChecksumComparator{}
+	}
+}
+
+// Comparator defines interface for determining whether the destination `dst` is identical
+// to the source `src`.
+type Comparator interface {
+	SameAs(ctx context.Context, dst, src *PathInfo) bool
+}
+
+// ChecksumComparator implements the Comparator by comparing the size and the checksum,
+// see `PathInfo.SameAs`.
+type ChecksumComparator struct{}
+
+// SameAs checks whether `dst` has the same size and checksum as `src`.
+func (ChecksumComparator) SameAs(ctx context.Context, dst, src *PathInfo) bool {
+	return dst.SameAs(ctx, src)
+}
+
+// SizeModTimeComparator implements the Comparator by comparing the size and the modification
+// time in seconds.  No file is read.
+type SizeModTimeComparator struct{}
+
+// SameAs checks whether `dst` has the same size and modification time as `src`.
+func (SizeModTimeComparator) SameAs(ctx context.Context, dst, src *PathInfo) bool {
+	if dst.Size != src.Size || dst.ModTime.IsZero() || src.ModTime.IsZero() {
+		return false
+	}
+	return OriginalModTime(ctx, *dst).Unix() == OriginalModTime(ctx, *src).Unix()
+}
+
+// AlwaysComparator implements the Comparator that considers no file identical.
+type AlwaysComparator struct{}
+
+// SameAs always returns `false`.
+func (AlwaysComparator) SameAs(ctx context.Context, dst, src *PathInfo) bool {
+	return false
+}
+
+// OriginalModTime returns the modification time of the original file of the path `p`.  For
+// iRODS, it is the time recorded as `ModTimeAttribute`, or the modify time of the data object
+// if it is not recorded.
+func OriginalModTime(ctx context.Context, p PathInfo) time.Time {
+	if p.Type != TypeIrods {
+		return p.ModTime
+	}
+
+	metas, err := ctx.Value(dr.KeyFilesystem).(*fs.FileSystem).ListMetadata(p.Path)
+	if err != nil {
+		log.Debugf("cannot get metadata of %s: %s\n", p.Path, err)
+		return p.ModTime
+	}
+
+	for _, m := range metas {
+		if m.Name != ModTimeAttribute {
+			continue
+		}
+		if sec, err := strconv.ParseInt(m.Value, 10, 64); err == nil {
+			return time.Unix(sec, 0)
+		}
+	}
+	return p.ModTime
+}
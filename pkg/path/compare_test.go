@@ -0,0 +1,61 @@
+package path
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestComparator(t *testing.T) {
+
+	dir := t.TempDir()
+
+	mtime := time.Unix(1700000000, 0)
+	write := func(name, data string) PathInfo {
+		fpath := filepath.Join(dir, name)
+		if err := os.WriteFile(fpath, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(fpath, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+		fi, err := os.Stat(fpath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return PathInfo{Path: fpath, Type: TypeFileSystem, Mode: fi.Mode(), Size: fi.Size(), ModTime: fi.ModTime()}
+	}
+
+	src := write("src", "hello")
+	same := write("same", "hello")
+	changed := write("changed", "world") // same size and mtime, different content
+
+	cases := []struct {
+		strategy CompareStrategy
+		dst      PathInfo
+		expected bool
+	}{
+		{CompareChecksum, same, true},
+		{CompareChecksum, changed, false},
+		{CompareSizeModTime, same, true},
+		{CompareSizeModTime, changed, true},
+		{CompareAlways, same, false},
+	}
+
+	ctx := context.Background()
+	for _, c := range cases {
+		if got := NewComparator(c.strategy).SameAs(ctx, &c.dst, &src); got != c.expected {
+			t.Errorf("%s: %s same as %s: expected %v, got %v", c.strategy, c.dst.Path, src.Path, c.expected, got)
+		}
+	}
+
+	if s, err := ParseCompareStrategy(""); err != nil || s != CompareChecksum {
+		t.Errorf("empty strategy: expected %s, got %s (%v)", CompareChecksum, s, err)
+	}
+
+	if _, err := ParseCompareStrategy("mtime"); err == nil {
+		t.Errorf("unknown strategy accepted")
+	}
+}
@@ -0,0 +1,53 @@
+package path
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ConflictPolicy defines how a file is handled when it exists at the destination and differs
+// from the source file.
+type ConflictPolicy string
+
+const (
+	// ConflictOverwrite overwrites the file at the destination.
+	ConflictOverwrite ConflictPolicy = "overwrite"
+	// ConflictSkip keeps the file at the destination, the source file is not transferred.
+	ConflictSkip ConflictPolicy = "skip"
+	// ConflictRename keeps both files by renaming the file at the destination with a timestamp
+	// suffix, see `RenamedPath`, before the source file is transferred.
+	ConflictRename ConflictPolicy = "rename"
+	// ConflictFail keeps the file at the destination, and the source file is counted as failed.
+	ConflictFail ConflictPolicy = "fail"
+)
+
+// ParseConflictPolicy converts the string `s` into a ConflictPolicy.  An empty string
+// is the `ConflictOverwrite` policy.
+func ParseConflictPolicy(s string) (ConflictPolicy, error) {
+	switch p := ConflictPolicy(s); p {
+	case "":
+		return ConflictOverwrite, nil
+	case ConflictOverwrite, ConflictSkip, ConflictRename, ConflictFail:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown conflict policy %q", s)
+	}
+}
+
+// RenamedPath returns the path to which the existing file `p` is renamed at the time `t` by
+// the `ConflictRename` policy.  The timestamp is inserted before the extension of the file
+// name, e.g. `data.txt` is renamed to `data.20060102T150405.txt`.
+func RenamedPath(p string, t time.Time) string {
+	dir, name := filepath.Split(p)
+	ts := t.Format("20060102T150405")
+
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	if stem == "" || strings.HasSuffix(stem, ".") {
+		// hidden file without extension, e.g. `.bashrc`
+		return filepath.Join(dir, fmt.Sprintf("%s.%s", name, ts))
+	}
+	return filepath.Join(dir, fmt.Sprintf("%s.%s%s", stem, ts, ext))
+}
@@ -0,0 +1,29 @@
+package path
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRenamedPath(t *testing.T) {
+
+	ts := time.Date(2024, 3, 1, 12, 30, 45, 0, time.UTC)
+
+	cases := map[string]string{
+		"/project/data.txt":       "/project/data.20240301T123045.txt",
+		"/project/sub-01.nii.gz":  "/project/sub-01.nii.20240301T123045.gz",
+		"/project/README":         "/project/README.20240301T123045",
+		"/project/.bashrc":        "/project/.bashrc.20240301T123045",
+		"/nl.ru.donders/coll/a.b": "/nl.ru.donders/coll/a.20240301T123045.b",
+	}
+
+	for p, expected := range cases {
+		if got := RenamedPath(p, ts); got != expected {
+			t.Errorf("%s: expected %s, got %s", p, expected, got)
+		}
+	}
+
+	if _, err := ParseConflictPolicy("keep"); err == nil {
+		t.Errorf("unknown policy accepted")
+	}
+}
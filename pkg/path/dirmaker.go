@@ -6,16 +6,23 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"syscall"
 
-	"github.com/dccn-tg/dr-data-stager/internal/worker/config"
 	"github.com/dccn-tg/dr-data-stager/pkg/dr"
 	"github.com/cyverse/go-irodsclient/fs"
 	log "github.com/dccn-tg/tg-toolset-golang/pkg/logger"
 )
 
+// Default permission modes of the files and directories created on the local filesystem.
+const (
+	DefaultFileMode os.FileMode = 0664
+	DefaultDirMode  os.FileMode = 0775
+)
+
 // NewDirMaker determines the path type and returns a corresponding
-// implementation of the DirMaker interface.
-func NewDirMaker(path PathInfo, config config.Configuration) DirMaker {
+// implementation of the DirMaker interface.  Directories created on the local
+// filesystem get the permission `mode`.
+func NewDirMaker(path PathInfo, mode os.FileMode) DirMaker {
 	switch path.Type {
 	case TypeIrods:
 		return IrodsCollectionMaker{
@@ -24,6 +31,7 @@ func NewDirMaker(path PathInfo, config config.Configuration) DirMaker {
 	default:
 		return FileSystemDirMaker{
 			base: path.Path,
+			mode: mode,
 		}
 	}
 }
@@ -37,9 +45,12 @@ type DirMaker interface {
 type FileSystemDirMaker struct {
 	// Base is the top-level directory.
 	base string
+	// mode is the permission mode of the created directories.
+	mode os.FileMode
 }
 
-// Mkdir ensures the directory referred by the path is created.
+// Mkdir ensures the directory referred by the path is created.  Directories created are
+// given the permission mode of the DirMaker, existing directories are left untouched.
 func (m FileSystemDirMaker) Mkdir(ctx context.Context, path string) error {
 
 	if !strings.HasPrefix(path, m.base) {
@@ -48,7 +59,35 @@ func (m FileSystemDirMaker) Mkdir(ctx context.Context, path string) error {
 
 	log.Debugf("creating directory %s", path)
 
-	return os.MkdirAll(path, 0775)
+	return mkdirAll(path, m.mode)
+}
+
+// mkdirAll creates the directory `path` along with the missing parents, like `os.MkdirAll`.
+// The created directories get the permission `mode` regardless of the umask.
+func mkdirAll(path string, mode os.FileMode) error {
+
+	if fi, err := os.Stat(path); err == nil {
+		if fi.IsDir() {
+			return nil
+		}
+		return &os.PathError{Op: "mkdir", Path: path, Err: syscall.ENOTDIR}
+	}
+
+	if parent := filepath.Dir(path); parent != path {
+		if err := mkdirAll(parent, mode); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Mkdir(path, mode); err != nil {
+		// the directory may be created by another sync worker in the meantime
+		if os.IsExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	return os.Chmod(path, mode)
 }
 
 // IrodsCollectionMaker implements the DirMaker for iRODS, using the `imkdir` system call.
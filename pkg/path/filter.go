@@ -0,0 +1,104 @@
+package path
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Filter defines the rules for selecting files when scanning a directory.  Rules are
+// applied to the path relative to the top-level directory of the scan.
+//
+// Patterns follow the syntax of `path.Match`.  A pattern without a slash is matched
+// against the name of the file or directory, e.g. `*.tmp`; otherwise it is matched
+// against the relative path, e.g. `sub-*/anat/*.nii.gz`.  A pattern ending with a
+// slash only matches directories, e.g. `scratch/`.
+type Filter struct {
+	// Include is a list of patterns; if not empty, only files matching one of the
+	// patterns are selected.  Directories are always traversed.
+	Include []string
+
+	// Exclude is a list of patterns; files and directories matching one of the
+	// patterns are skipped.  Exclude rules take precedence over include rules.
+	Exclude []string
+
+	// MinSize is the minimum size of the file in bytes, 0 for no limit.
+	MinSize int64
+
+	// MaxSize is the maximum size of the file in bytes, 0 for no limit.
+	MaxSize int64
+}
+
+// Validate checks whether the patterns of the filter are valid.
+func (f Filter) Validate() error {
+	for _, p := range append(append([]string{}, f.Include...), f.Exclude...) {
+		if _, err := path.Match(strings.TrimSuffix(p, "/"), ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", p, err)
+		}
+	}
+
+	if f.MaxSize > 0 && f.MinSize > f.MaxSize {
+		return fmt.Errorf("minimum size %d is larger than maximum size %d", f.MinSize, f.MaxSize)
+	}
+
+	return nil
+}
+
+// SkipDir checks whether the directory with the relative path `rel` should be skipped
+// entirely.
+func (f Filter) SkipDir(rel string) bool {
+	rel = strings.Trim(rel, "/")
+	if rel == "" {
+		return false
+	}
+	return matchAny(f.Exclude, rel, true)
+}
+
+// Match checks whether the file with the relative path `rel` and `size` is selected.
+func (f Filter) Match(rel string, size int64) bool {
+
+	if f.MinSize > 0 && size < f.MinSize {
+		return false
+	}
+
+	if f.MaxSize > 0 && size > f.MaxSize {
+		return false
+	}
+
+	return f.matchPath(rel)
+}
+
+// matchPath checks whether the file with the relative path `rel` is selected by the
+// patterns, regardless of the size.
+func (f Filter) matchPath(rel string) bool {
+
+	rel = strings.Trim(rel, "/")
+
+	if matchAny(f.Exclude, rel, false) {
+		return false
+	}
+
+	return len(f.Include) == 0 || matchAny(f.Include, rel, false)
+}
+
+// matchAny checks whether the relative path `rel` matches one of the `patterns`.
+func matchAny(patterns []string, rel string, isDir bool) bool {
+	for _, p := range patterns {
+		if strings.HasSuffix(p, "/") {
+			if !isDir {
+				continue
+			}
+			p = strings.TrimSuffix(p, "/")
+		}
+
+		target := rel
+		if !strings.Contains(p, "/") {
+			target = path.Base(rel)
+		}
+
+		if ok, _ := path.Match(p, target); ok {
+			return true
+		}
+	}
+	return false
+}
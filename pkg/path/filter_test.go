@@ -0,0 +1,54 @@
+package path
+
+import "testing"
+
+func TestFilter(t *testing.T) {
+
+	f := Filter{
+		Include: []string{"*.nii.gz", "sub-*/beh/*.tsv"},
+		Exclude: []string{"*.tmp", "scratch/", "sub-02"},
+		MaxSize: 1024,
+	}
+
+	if err := f.Validate(); err != nil {
+		t.Fatalf("%s\n", err)
+	}
+
+	files := map[string]bool{
+		"sub-01/anat/T1w.nii.gz":     true,
+		"sub-01/anat/T1w.json":       false,
+		"sub-01/beh/events.tsv":      true,
+		"sub-01/func/events.tsv":     false,
+		"sub-01/anat/T1w.nii.gz.tmp": false,
+		"/sub-01/dwi/dwi.nii.gz":     true,
+	}
+
+	for rel, expected := range files {
+		if f.Match(rel, 100) != expected {
+			t.Errorf("%s: expect match %t", rel, expected)
+		}
+	}
+
+	if f.Match("sub-01/anat/T1w.nii.gz", 2048) {
+		t.Errorf("file larger than the maximum size is selected")
+	}
+
+	dirs := map[string]bool{
+		"":                true,
+		"scratch":         false,
+		"sub-01/scratch":  false,
+		"sub-02":          false,
+		"sub-01":          true,
+		"sub-01/scratch2": true,
+	}
+
+	for rel, expected := range dirs {
+		if f.SkipDir(rel) == expected {
+			t.Errorf("%s: expect traversal %t", rel, expected)
+		}
+	}
+
+	if err := (Filter{Exclude: []string{"[a-"}}).Validate(); err == nil {
+		t.Errorf("expect error on invalid pattern")
+	}
+}
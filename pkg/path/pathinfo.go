@@ -2,12 +2,11 @@ package path
 
 import (
 	"context"
-	"crypto/sha256"
 	"fmt"
-	"io"
 	"os"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/cyverse/go-irodsclient/fs"
 	"github.com/dccn-tg/dr-data-stager/pkg/dr"
@@ -24,56 +23,106 @@ type PathInfo struct {
 	Mode os.FileMode
 	// Size
 	Size int64
-	// checksum
-	checksum string
+	// ModTime is the last modification time.
+	ModTime time.Time
+	// LinkTarget is the target of the symbolic link, if the path is a link not being followed.
+	LinkTarget string
+	// checksum registered in iRODS, or computed for local file
+	checksum Checksum
 }
 
-func (p PathInfo) CountFiles(ctx context.Context) int {
-	if p.Mode.IsRegular() {
-		return 1
+// IsSymlink checks whether the path is a symbolic link.
+func (p PathInfo) IsSymlink() bool {
+	return p.Mode&os.ModeSymlink != 0
+}
+
+// GetChecksum returns the checksum of the path in the normalized form, see `Checksum.String`.
+//
+// For iRODS, it is the checksum registered in iRODS, or an empty string if the checksum is
+// not available.  For local file, it is the checksum computed with the algorithm of a previous
+// call of `ChecksumOf`, or with the `DefaultChecksumAlgorithm`.
+func (p *PathInfo) GetChecksum() string {
+	if p.Type == TypeIrods || !p.checksum.IsEmpty() {
+		return p.checksum.String()
+	}
+
+	c, err := p.ChecksumOf(DefaultChecksumAlgorithm)
+	if err != nil {
+		log.Errorf("%s\n", err)
+		return ""
 	}
-	scanner := NewScanner(p)
-	return scanner.CountFilesInDir(ctx, p.Path)
+	return c.String()
 }
 
-func (p PathInfo) GetChecksum() string {
-	if p.checksum != "" {
-		return p.checksum
+// RegisteredChecksum returns the checksum registered in iRODS, or an empty checksum if it is
+// not available or the path is not in iRODS.
+func (p PathInfo) RegisteredChecksum() Checksum {
+	if p.Type != TypeIrods {
+		return Checksum{}
 	}
+	return p.checksum
+}
 
-	if p.Type == TypeFileSystem {
-		// calculate checksum for local file
-		f, err := os.Open(p.Path)
-		if err != nil {
-			log.Errorf("%s\n", err)
+// ChecksumOf returns the checksum of the path computed with the algorithm `alg`.
+//
+// For iRODS, it returns the registered checksum, and an error if the checksum is not
+// available or it is of a different algorithm.  For local file, the checksum is kept in `p`
+// and in the checksum cache of the file, see `CachedChecksum`.
+func (p *PathInfo) ChecksumOf(alg ChecksumAlgorithm) (Checksum, error) {
+	if p.Type == TypeIrods {
+		if p.checksum.IsEmpty() {
+			return Checksum{}, fmt.Errorf("no checksum available for %s", p.Path)
 		}
-		defer f.Close()
-
-		h := sha256.New()
-		if _, err := io.Copy(h, f); err != nil {
-			log.Errorf("%s\n", err)
+		if p.checksum.Algorithm != alg {
+			return Checksum{}, fmt.Errorf("checksum of %s is not in %s", p.Path, alg)
 		}
+		return p.checksum, nil
+	}
 
-		p.checksum = fmt.Sprintf("%x", h.Sum(nil))
+	if p.checksum.Algorithm == alg && !p.checksum.IsEmpty() {
+		return p.checksum, nil
 	}
 
-	return p.checksum
+	c, err := CachedChecksum(p.Path, alg)
+	if err != nil {
+		return c, err
+	}
+	p.checksum = c
+	return c, nil
 }
 
-func (p PathInfo) SameAs(ctx context.Context, o PathInfo) bool {
+// SameAs checks whether the path `p` has the same content as the path `o`, by comparing
+// the size and the checksum.
+//
+// The checksum algorithm is determined by the checksum registered in iRODS, so that the
+// local checksum is computed with the same algorithm as the iRODS zone is configured with.
+func (p *PathInfo) SameAs(ctx context.Context, o *PathInfo) bool {
 
 	if p.Size != o.Size {
 		return false
 	}
 
-	sum1 := o.GetChecksum()
-	sum2 := p.GetChecksum()
+	alg := DefaultChecksumAlgorithm
+	switch {
+	case p.Type == TypeIrods && !p.checksum.IsEmpty():
+		alg = p.checksum.Algorithm
+	case o.Type == TypeIrods && !o.checksum.IsEmpty():
+		alg = o.checksum.Algorithm
+	}
 
-	if sum1 == "" || sum2 == "" {
+	sum1, err := o.ChecksumOf(alg)
+	if err != nil {
+		log.Debugf("%s\n", err)
+		return false
+	}
+
+	sum2, err := p.ChecksumOf(alg)
+	if err != nil {
+		log.Debugf("%s\n", err)
 		return false
 	}
 
-	return sum1 == sum2
+	return sum1.Equal(sum2)
 }
 
 // GetPathInfo resolves the PathInfo of the given path.
@@ -99,8 +148,9 @@ func GetPathInfo(ctx context.Context, path string) (PathInfo, error) {
 			info.Mode = 0
 
 			// iRODS file entry contains checksum if it is available
-			info.checksum = fmt.Sprintf("%x", entry.CheckSum)
+			info.checksum = NewIrodsChecksum(entry.CheckSumAlgorithm, entry.CheckSum)
 			info.Size = entry.Size
+			info.ModTime = entry.ModifyTime
 			return info, nil
 		}
 
@@ -123,6 +173,7 @@ func GetPathInfo(ctx context.Context, path string) (PathInfo, error) {
 
 	info.Mode = fi.Mode()
 	info.Size = fi.Size()
+	info.ModTime = fi.ModTime()
 
 	return info, nil
 }
@@ -3,6 +3,7 @@ package path
 import (
 	"context"
 	"io/fs"
+	"os"
 	"path/filepath"
 	"strings"
 
@@ -12,13 +13,15 @@ import (
 )
 
 // NewScanner determines the path type and returns a corresponding
-// implementation of the Scanner interface.
-func NewScanner(path PathInfo) Scanner {
+// implementation of the Scanner interface.  Files and directories under
+// the path are selected by the `filter`.  Symbolic links in a local directory
+// are handled according to the policy `links`.
+func NewScanner(path PathInfo, filter Filter, links SymlinkPolicy) Scanner {
 	switch path.Type {
 	case TypeIrods:
-		return IrodsCollectionScanner{base: path}
+		return IrodsCollectionScanner{base: path, filter: filter}
 	default:
-		return FileSystemScanner{base: path}
+		return FileSystemScanner{base: path, filter: filter, links: links}
 	}
 }
 
@@ -34,26 +37,25 @@ type Scanner interface {
 	//
 	// For example, it can be that the Scanner is implemented to loop over a local filesystem using
 	// the `filepath.Walk`, while the `dirmaker` is implemented to create a remote iRODS collection.
-	ScanMakeDir(ctx context.Context, buffer int, dirmaker *DirMaker) chan string
-
-	// CountFilesInDir counts number of files in a given directory
-	CountFilesInDir(ctx context.Context, dir string) int
+	ScanMakeDir(ctx context.Context, buffer int, dirmaker *DirMaker) chan PathInfo
 }
 
 // FileSystemScanner implements the `Scanner` interface for a POSIX-compliant filesystem.
 type FileSystemScanner struct {
 	dirmaker *DirMaker
 	base     PathInfo
+	filter   Filter
+	links    SymlinkPolicy
 }
 
 // ScanMakeDir gets a list of files iteratively under a file system `path`, and performs directory
 // creation based on the implementation of the `dirmaker`.
 //
-// The output is a string channel with the buffer size provided by the `buffer` argument.
-// Each element of the channel refers to a file path.  The channel is closed at the end of the scan.
-func (s FileSystemScanner) ScanMakeDir(ctx context.Context, buffer int, dirmaker *DirMaker) chan string {
+// The output is a PathInfo channel with the buffer size provided by the `buffer` argument.
+// Each element of the channel refers to a file.  The channel is closed at the end of the scan.
+func (s FileSystemScanner) ScanMakeDir(ctx context.Context, buffer int, dirmaker *DirMaker) chan PathInfo {
 
-	files := make(chan string, buffer)
+	files := make(chan PathInfo, buffer)
 
 	s.dirmaker = dirmaker
 
@@ -61,50 +63,66 @@ func (s FileSystemScanner) ScanMakeDir(ctx context.Context, buffer int, dirmaker
 		defer close(files)
 
 		if s.base.Mode.IsDir() {
-			s.goWalk(ctx, s.base.Path, false, &files)
+			s.goWalk(ctx, s.base.Path, s.base.Path, nil, &files)
 			//s.fastWalk(ctx, s.base.Path, false, &files)
 		} else {
-			files <- s.base.Path
+			files <- s.base
 		}
 	}()
 
 	return files
 }
 
-func (s FileSystemScanner) CountFilesInDir(ctx context.Context, dir string) int {
-
-	c := 0
-	files := make(chan string, 10000)
-	go func() {
-		s.goWalk(ctx, s.base.Path, false, &files)
-		//s.fastWalk(ctx, dir, false, &files)
-		defer close(files)
-	}()
-	for range files {
-		c++
-	}
-	return c
-}
-
-func (s FileSystemScanner) goWalk(ctx context.Context, root string, followLink bool, files *chan string) {
+// goWalk walks through the directory `root` and pushes the files to the `files` channel.
+//
+// Paths are reported with `root` replaced by `prefix`, so that files in a directory reached
+// by following a symbolic link appear under the path of the link.  `parents` are the resolved
+// directories in which the followed links are located, they are used for detecting loops.
+func (s FileSystemScanner) goWalk(ctx context.Context, root, prefix string, parents []string, files *chan PathInfo) {
 
 	filepath.WalkDir(root, func(p string, d fs.DirEntry, e error) error {
 
 		if e != nil {
 			log.Warnf("skip file: %s due to %s\n", p, e)
+			return nil
 		}
 
+		// path of the file as it appears under the top-level directory of the scan
+		p = prefix + strings.TrimPrefix(p, root)
+		rel := strings.TrimPrefix(p, s.base.Path)
+
 		switch {
 		case d.Type().IsDir():
+			if s.filter.SkipDir(rel) {
+				log.Debugf("skip directory: %s\n", p)
+				return filepath.SkipDir
+			}
 			if s.dirmaker != nil {
 				if err := (*s.dirmaker).Mkdir(ctx, strings.TrimPrefix(p, s.base.Path)); err != nil {
 					log.Errorf("Mkdir failure: %s\n", err.Error())
 				}
 			}
 		case d.Type().IsRegular():
-			*files <- p
+			if IsTempPath(p) {
+				return nil
+			}
+			fi, err := d.Info()
+			if err != nil {
+				log.Warnf("skip file: %s due to %s\n", p, err)
+				return nil
+			}
+			if !s.filter.Match(rel, fi.Size()) {
+				return nil
+			}
+			*files <- PathInfo{
+				Path:    p,
+				Type:    TypeFileSystem,
+				Mode:    fi.Mode(),
+				Size:    fi.Size(),
+				ModTime: fi.ModTime(),
+			}
 		case d.Type() == fs.ModeSymlink:
-			log.Warnf("skip symlink: %s\n", p)
+			s.walkLink(ctx, p, rel, parents, files)
 		default:
 			log.Warnf("skip unsupported file type: %s\n", p)
 		}
@@ -113,32 +131,109 @@ func (s FileSystemScanner) goWalk(ctx context.Context, root string, followLink b
 	})
 }
 
+// walkLink handles the symbolic link `p` according to the symlink policy of the scanner.
+//
+// A followed link is resolved and the file or directory it refers to is scanned.  Otherwise,
+// the link itself is pushed to the `files` channel to be recorded or reported as skipped.
+func (s FileSystemScanner) walkLink(ctx context.Context, p, rel string, parents []string, files *chan PathInfo) {
+
+	if s.links != SymlinkFollow {
+		if !s.filter.matchPath(rel) {
+			return
+		}
+
+		fi, err := os.Lstat(p)
+		if err != nil {
+			log.Warnf("skip symlink: %s due to %s\n", p, err)
+			return
+		}
+
+		target, err := os.Readlink(p)
+		if err != nil {
+			log.Warnf("skip symlink: %s due to %s\n", p, err)
+			return
+		}
+
+		*files <- PathInfo{
+			Path:       p,
+			Type:       TypeFileSystem,
+			Mode:       fi.Mode(),
+			ModTime:    fi.ModTime(),
+			LinkTarget: target,
+		}
+		return
+	}
+
+	target, err := filepath.EvalSymlinks(p)
+	if err != nil {
+		log.Warnf("skip symlink: %s due to %s\n", p, err)
+		return
+	}
+
+	fi, err := os.Stat(target)
+	if err != nil {
+		log.Warnf("skip symlink: %s due to %s\n", p, err)
+		return
+	}
+
+	switch {
+	case fi.IsDir():
+		parent, err := filepath.EvalSymlinks(filepath.Dir(p))
+		if err != nil {
+			log.Warnf("skip symlink: %s due to %s\n", p, err)
+			return
+		}
+		parents = append(parents, parent)
+		if isLoop(target, parents) {
+			log.Warnf("skip symlink: %s refers to its parent directory %s\n", p, target)
+			return
+		}
+		s.goWalk(ctx, target, p, parents, files)
+	case fi.Mode().IsRegular():
+		if !s.filter.Match(rel, fi.Size()) {
+			return
+		}
+		*files <- PathInfo{
+			Path:    p,
+			Type:    TypeFileSystem,
+			Mode:    fi.Mode(),
+			Size:    fi.Size(),
+			ModTime: fi.ModTime(),
+		}
+	default:
+		log.Warnf("skip symlink: %s refers to unsupported file type\n", p)
+	}
+}
+
 // IrodsCollectionScanner implements the `Scanner` interface for iRODS.
 type IrodsCollectionScanner struct {
 	base     PathInfo
 	dirmaker *DirMaker
+	filter   Filter
 }
 
 // ScanMakeDir gets a list of data objects iteratively under a iRODS collection `path`, and performs
 // directory creation based on the implementation of `dirmaker`.
 //
-// The output is a string channel with the buffer size provided by the `buffer` argument.
+// The output is a PathInfo channel with the buffer size provided by the `buffer` argument.
 // Each element of the channel refers to an iRODS data object.  The channel is closed at the end of the scan.
-func (s IrodsCollectionScanner) ScanMakeDir(ctx context.Context, buffer int, dirmaker *DirMaker) chan string {
+func (s IrodsCollectionScanner) ScanMakeDir(ctx context.Context, buffer int, dirmaker *DirMaker) chan PathInfo {
 
-	files := make(chan string, buffer)
+	files := make(chan PathInfo, buffer)
 
 	s.dirmaker = dirmaker
 
 	go func() {
 		if s.base.Mode.IsDir() {
 			// ensure the top-level directory at destination exist
-			if err := (*s.dirmaker).Mkdir(ctx, ""); err != nil {
-				log.Errorf("Mkdir failure: %s", err.Error())
+			if s.dirmaker != nil {
+				if err := (*s.dirmaker).Mkdir(ctx, ""); err != nil {
+					log.Errorf("Mkdir failure: %s", err.Error())
+				}
 			}
 			s.collWalk(ctx, s.base.Path, &files)
 		} else {
-			files <- s.base.Path
+			files <- s.base
 		}
 		defer close(files)
 	}()
@@ -146,24 +241,11 @@ func (s IrodsCollectionScanner) ScanMakeDir(ctx context.Context, buffer int, dir
 	return files
 }
 
-func (s IrodsCollectionScanner) CountFilesInDir(ctx context.Context, dir string) int {
-	c := 0
-	files := make(chan string, 10000)
-	go func() {
-		s.collWalk(ctx, dir, &files)
-		defer close(files)
-	}()
-	for range files {
-		c++
-	}
-	return c
-}
-
 // collWalk uses the "iquest" command to query file objects and sub-collections within the collection referred
 // by `path`.  It pushs file objects to the `files` channel and loop over the sub-collections iteratively.
 //
 // The caller is responsible for closing the `files` channel.
-func (s IrodsCollectionScanner) collWalk(ctx context.Context, path string, files *chan string) {
+func (s IrodsCollectionScanner) collWalk(ctx context.Context, path string, files *chan PathInfo) {
 
 	entries, err := ctx.Value(dr.KeyFilesystem).(*ifs.FileSystem).List(path)
 	if err != nil {
@@ -191,12 +273,27 @@ func (s IrodsCollectionScanner) collWalk(ctx context.Context, path string, files
 				// no more entries to handle
 				return
 			}
+			rel := strings.TrimPrefix(entry.Path, s.base.Path)
 			if entry.Type == ifs.FileEntry {
-				*files <- entry.Path
+				// temporary data objects of the transfers are not data
+				if IsTempPath(entry.Path) || !s.filter.Match(rel, entry.Size) {
+					continue
+				}
+				*files <- PathInfo{
+					Path:     entry.Path,
+					Type:     TypeIrods,
+					Size:     entry.Size,
+					ModTime:  entry.ModifyTime,
+					checksum: NewIrodsChecksum(entry.CheckSumAlgorithm, entry.CheckSum),
+				}
 			} else {
+				if s.filter.SkipDir(rel) {
+					log.Debugf("skip collection: %s", entry.Path)
+					continue
+				}
 				if s.dirmaker != nil {
 					// perform `MakeDir` with the `dirmaker`
-					if err := (*s.dirmaker).Mkdir(ctx, strings.TrimPrefix(entry.Path, s.base.Path)); err != nil {
+					if err := (*s.dirmaker).Mkdir(ctx, rel); err != nil {
 						log.Errorf("Mkdir failure: %s", err.Error())
 					}
 				}
@@ -0,0 +1,53 @@
+package path
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// SymlinkPolicy defines how a symbolic link in a local source directory is handled.
+type SymlinkPolicy string
+
+const (
+	// SymlinkSkip skips the symbolic link.  The skipped links are reported in the job status.
+	SymlinkSkip SymlinkPolicy = "skip"
+	// SymlinkFollow transfers the file or the directory the link refers to, as if it is
+	// located at the path of the link.  Links causing a loop are skipped.
+	SymlinkFollow SymlinkPolicy = "follow"
+	// SymlinkRecord creates an empty placeholder data object in iRODS for the link, with
+	// the link target recorded as the metadata attribute `SymlinkAttribute`.
+	SymlinkRecord SymlinkPolicy = "record"
+)
+
+// SymlinkAttribute is the name of the iRODS metadata attribute in which the target of a
+// symbolic link is recorded on the placeholder data object.
+const SymlinkAttribute = "stager.symlink.target"
+
+// ParseSymlinkPolicy converts the string `s` into a SymlinkPolicy.  An empty string
+// is the `SymlinkSkip` policy.
+func ParseSymlinkPolicy(s string) (SymlinkPolicy, error) {
+	switch p := SymlinkPolicy(s); p {
+	case "":
+		return SymlinkSkip, nil
+	case SymlinkSkip, SymlinkFollow, SymlinkRecord:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown symlink policy %q", s)
+	}
+}
+
+// isLoop checks whether the directory `dir` is one of the `parents`, or an ancestor of
+// them.  Following a link to such a directory would walk into the same tree again.
+func isLoop(dir string, parents []string) bool {
+	for _, p := range parents {
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			continue
+		}
+		if rel != ".." && !strings.HasPrefix(rel, "../") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package path
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	log "github.com/dccn-tg/tg-toolset-golang/pkg/logger"
+)
+
+func init() {
+	// setup logger
+	log.NewLogger(
+		log.Configuration{
+			EnableConsole:     true,
+			ConsoleJSONFormat: false,
+			ConsoleLevel:      log.Debug,
+		},
+		log.InstanceLogrusLogger,
+	)
+}
+
+// scanFiles returns the paths relative to `dir` of the files scanned with the symlink policy `links`.
+func scanFiles(t *testing.T, dir string, links SymlinkPolicy) map[string]PathInfo {
+	base := PathInfo{Path: dir, Type: TypeFileSystem, Mode: fs.ModeDir}
+	files := make(map[string]PathInfo)
+	for f := range NewScanner(base, Filter{}, links).ScanMakeDir(context.Background(), 10, nil) {
+		files[strings.TrimPrefix(f.Path, dir+"/")] = f
+	}
+	return files
+}
+
+func TestSymlinkPolicy(t *testing.T) {
+
+	root := t.TempDir()
+	src := filepath.Join(root, "project")
+	atlas := filepath.Join(root, "atlas")
+
+	for _, d := range []string{filepath.Join(src, "sub-01"), atlas} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatalf("%s\n", err)
+		}
+	}
+
+	for _, f := range []string{filepath.Join(src, "sub-01", "data.txt"), filepath.Join(atlas, "atlas.txt")} {
+		if err := os.WriteFile(f, []byte("data"), 0644); err != nil {
+			t.Fatalf("%s\n", err)
+		}
+	}
+
+	links := map[string]string{
+		filepath.Join(src, "atlas"):              atlas,
+		filepath.Join(src, "sub-01", "loop"):     src,
+		filepath.Join(src, "sub-01", "copy.txt"): "data.txt",
+		filepath.Join(atlas, "project"):          src,
+	}
+	for l, target := range links {
+		if err := os.Symlink(target, l); err != nil {
+			t.Fatalf("%s\n", err)
+		}
+	}
+
+	keys := func(m map[string]PathInfo) []string {
+		var s []string
+		for k := range m {
+			s = append(s, k)
+		}
+		sort.Strings(s)
+		return s
+	}
+
+	// links causing a loop are not followed.
+	followed := scanFiles(t, src, SymlinkFollow)
+	expected := []string{"atlas/atlas.txt", "sub-01/copy.txt", "sub-01/data.txt"}
+	if got := keys(followed); strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Errorf("follow: expect %v, got %v", expected, got)
+	}
+	if f := followed["sub-01/copy.txt"]; f.IsSymlink() || f.Size != 4 {
+		t.Errorf("follow: expect regular file of 4 bytes, got %+v", f)
+	}
+
+	recorded := scanFiles(t, src, SymlinkRecord)
+	expected = []string{"atlas", "sub-01/copy.txt", "sub-01/data.txt", "sub-01/loop"}
+	if got := keys(recorded); strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Errorf("record: expect %v, got %v", expected, got)
+	}
+	if f := recorded["atlas"]; !f.IsSymlink() || f.LinkTarget != atlas {
+		t.Errorf("record: expect link to %s, got %+v", atlas, f)
+	}
+}
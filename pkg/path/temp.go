@@ -0,0 +1,37 @@
+package path
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+const (
+	// PartialSuffix is the suffix of the temporary file to which a file is transferred
+	// before it is renamed into place.
+	PartialSuffix = ".part"
+
+	// ReplacedSuffix is the suffix of the temporary name of a data object being replaced.
+	ReplacedSuffix = ".old"
+)
+
+// TempPath returns the path of the hidden temporary file of the file `p` with the `suffix`,
+// created by the stager task `id`, i.e. `.<name>.stager-<id><suffix>` in the same directory.
+func TempPath(p, id, suffix string) string {
+	dir, name := filepath.Split(p)
+	if id == "" {
+		return filepath.Join(dir, fmt.Sprintf(".%s.stager%s", name, suffix))
+	}
+	return filepath.Join(dir, fmt.Sprintf(".%s.stager-%s%s", name, id, suffix))
+}
+
+// IsTempPath checks whether the file `p` is a temporary file of a stager task.  Temporary
+// files are never considered as data; they are skipped by the scanners.
+func IsTempPath(p string) bool {
+	name := filepath.Base(p)
+	for _, suffix := range []string{PartialSuffix, ReplacedSuffix} {
+		if ok, _ := filepath.Match(".*.stager*"+suffix, name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package path
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTempPath(t *testing.T) {
+
+	cases := []struct {
+		path, id, suffix string
+		expected         string
+	}{
+		{"/data/sub-01/anat.nii", "a1b2", PartialSuffix, "/data/sub-01/.anat.nii.stager-a1b2.part"},
+		{"/data/sub-01/anat.nii", "", PartialSuffix, "/data/sub-01/.anat.nii.stager.part"},
+		{"/nl.ru.donders/di/dccn/DAC_3010000.01/raw.dat", "a1b2", ReplacedSuffix, "/nl.ru.donders/di/dccn/DAC_3010000.01/.raw.dat.stager-a1b2.old"},
+		{"anat.nii", "a1b2", PartialSuffix, ".anat.nii.stager-a1b2.part"},
+	}
+
+	for _, c := range cases {
+		if got := TempPath(c.path, c.id, c.suffix); got != c.expected {
+			t.Errorf("%s: expected %s, got %s", c.path, c.expected, got)
+		}
+		if !IsTempPath(c.expected) {
+			t.Errorf("%s: not a temporary path", c.expected)
+		}
+	}
+}
+
+func TestIsTempPath(t *testing.T) {
+
+	cases := map[string]bool{
+		"/data/.anat.nii.stager-a1b2.part": true,
+		"/data/.anat.nii.stager.part":      true,
+		"/coll/.raw.dat.stager-a1b2.old":   true,
+		"/data/anat.nii":                   false,
+		"/data/anat.nii.part":              false,
+		"/data/.anat.nii.part":             false,
+		"/data/anat.nii.stager-a1b2.part":  false,
+		"/data/.anat.nii.stager-a1b2.tmp":  false,
+		"/data/.stager-bundle-0011.tar":    false,
+		"/data/.stager.part/anat.nii":      false,
+	}
+
+	for p, expected := range cases {
+		if got := IsTempPath(p); got != expected {
+			t.Errorf("%s: expected %t, got %t", p, expected, got)
+		}
+	}
+}
+
+func TestScanSkipsTempPath(t *testing.T) {
+
+	dir := t.TempDir()
+
+	data := filepath.Join(dir, "data.txt")
+	for _, f := range []string{data, TempPath(data, "a1b2", PartialSuffix), TempPath(data, "c3d4", ReplacedSuffix)} {
+		if err := os.WriteFile(f, []byte("data"), 0644); err != nil {
+			t.Fatalf("%s\n", err)
+		}
+	}
+
+	files := scanFiles(t, dir, SymlinkSkip)
+	if _, ok := files["data.txt"]; !ok || len(files) != 1 {
+		t.Errorf("expected only data.txt, got %v", files)
+	}
+}
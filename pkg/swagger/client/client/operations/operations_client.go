@@ -38,6 +38,8 @@ type ClientService interface {
 
 	GetJobID(params *GetJobIDParams, authInfo runtime.ClientAuthInfoWriter, opts ...ClientOption) (*GetJobIDOK, error)
 
+	GetJobIDFiles(params *GetJobIDFilesParams, authInfo runtime.ClientAuthInfoWriter, opts ...ClientOption) (*GetJobIDFilesOK, error)
+
 	GetJobs(params *GetJobsParams, authInfo runtime.ClientAuthInfoWriter, opts ...ClientOption) (*GetJobsOK, error)
 
 	GetJobsStatus(params *GetJobsStatusParams, authInfo runtime.ClientAuthInfoWriter, opts ...ClientOption) (*GetJobsStatusOK, error)
@@ -208,6 +210,45 @@ func (a *Client) GetJobID(params *GetJobIDParams, authInfo runtime.ClientAuthInf
 	panic(msg)
 }
 
+/*
+GetJobIDFiles gets transfer records of files processed by a stager job
+*/
+func (a *Client) GetJobIDFiles(params *GetJobIDFilesParams, authInfo runtime.ClientAuthInfoWriter, opts ...ClientOption) (*GetJobIDFilesOK, error) {
+	// TODO: Validate the params before sending
+	if params == nil {
+		params = NewGetJobIDFilesParams()
+	}
+	op := &runtime.ClientOperation{
+		ID:                 "GetJobIDFiles",
+		Method:             "GET",
+		PathPattern:        "/job/{id}/files",
+		ProducesMediaTypes: []string{"application/json"},
+		ConsumesMediaTypes: []string{"application/json"},
+		Schemes:            []string{"https"},
+		Params:             params,
+		Reader:             &GetJobIDFilesReader{formats: a.formats},
+		AuthInfo:           authInfo,
+		Context:            params.Context,
+		Client:             params.HTTPClient,
+	}
+	for _, opt := range opts {
+		opt(op)
+	}
+
+	result, err := a.transport.Submit(op)
+	if err != nil {
+		return nil, err
+	}
+	success, ok := result.(*GetJobIDFilesOK)
+	if ok {
+		return success, nil
+	}
+	// unexpected success response
+	// safeguard: normally, absent a default response, unknown success responses return an error above: so this is a codegen issue
+	msg := fmt.Sprintf("unexpected success response for GetJobIDFiles: API contract not enforced by server. Client expected to get an error, but got: %T", result)
+	panic(msg)
+}
+
 /*
 GetJobs gets all jobs of a user
 */
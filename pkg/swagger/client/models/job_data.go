@@ -7,6 +7,8 @@ package models
 
 import (
 	"context"
+	"encoding/json"
+	"strconv"
 
 	"github.com/go-openapi/errors"
 	"github.com/go-openapi/strfmt"
@@ -19,6 +21,26 @@ import (
 // swagger:model jobData
 type JobData struct {
 
+	// bandwidth limit of the transfer in bytes per second (0 for no limit); the transfer is further limited by the bandwidth cap of the worker shared by concurrent jobs
+	BandwidthLimit int64 `json:"bandwidthLimit,omitempty"`
+
+	// maximum size in bytes of the files in a tar bundle (0 for the default of 256 MiB)
+	BundleSize int64 `json:"bundleSize,omitempty"`
+
+	// upload files smaller than the size in bytes in tar bundles, grouped by directory (0 for no bundling)
+	BundleThreshold int64 `json:"bundleThreshold,omitempty"`
+
+	// strategy to detect identical files that are skipped; checksum (default) compares the sizes and checksums, size-mtime compares the sizes and modification times without reading the files, always transfers all files
+	// Enum: [checksum size-mtime always]
+	Compare string `json:"compare,omitempty"`
+
+	// handling of files existing at the destination that differ from the source; overwrite (default) overwrites them, skip keeps them without transferring the source files, rename keeps them by renaming them with a timestamp suffix, fail keeps them and counts the source files as failed
+	// Enum: [overwrite skip rename fail]
+	Conflict string `json:"conflict,omitempty"`
+
+	// continue with remaining files when a file fails to be transferred; the job is then completed with failures instead of being retried
+	ContinueOnError bool `json:"continueOnError,omitempty"`
+
 	// password of the DR data-access account
 	DrPass string `json:"drPass,omitempty"`
 
@@ -30,6 +52,30 @@ type JobData struct {
 	// Required: true
 	DstURL *string `json:"dstURL"`
 
+	// skip files and directories matching one of the glob patterns, e.g. *.tmp or scratch/
+	Exclude []string `json:"exclude"`
+
+	// only transfer files matching one of the glob patterns, e.g. *.nii.gz
+	Include []string `json:"include"`
+
+	// maximum number of files allowed to be removed in the mirror mode (0 for the default of 1000); nothing is removed if the limit is exceeded
+	MaxDeletions int64 `json:"maxDeletions,omitempty"`
+
+	// skip files larger than the size in bytes (0 for no limit)
+	MaxSize int64 `json:"maxSize,omitempty"`
+
+	// iRODS metadata attached to the uploaded data objects and the top-level collection
+	Metadata []*JobMetadata `json:"metadata"`
+
+	// skip files smaller than the size in bytes
+	MinSize int64 `json:"minSize,omitempty"`
+
+	// remove files at the destination that are not present at the source, after a successful transfer
+	Mirror bool `json:"mirror,omitempty"`
+
+	// only plan the transfer without moving data; the job status reports the numbers of files to be copied, overwritten, skipped or removed, and the total size to be transferred
+	PlanOnly bool `json:"planOnly,omitempty"`
+
 	// path or DR namespace (prefixed with irods:) of the source endpoint
 	// Required: true
 	SrcURL *string `json:"srcURL"`
@@ -42,6 +88,10 @@ type JobData struct {
 	// Format: email
 	StagerUserEmail strfmt.Email `json:"stagerUserEmail,omitempty"`
 
+	// handling of symbolic links in a local source directory; skip (default) skips the links and reports them in the job status, follow transfers the files the links refer to, record creates empty placeholder data objects with the link targets recorded as metadata
+	// Enum: [skip follow record]
+	Symlinks string `json:"symlinks,omitempty"`
+
 	// allowed duration in seconds for entire transfer job (0 for no timeout)
 	Timeout int64 `json:"timeout,omitempty"`
 
@@ -51,12 +101,26 @@ type JobData struct {
 	// short description about the job
 	// Required: true
 	Title *string `json:"title"`
+
+	// unpack the tar bundles into the original files when downloading from iRODS
+	Unpack bool `json:"unpack,omitempty"`
+
+	// verify each transferred file by comparing the checksum of the source and the destination; a mismatch is counted as a failed file
+	Verify bool `json:"verify,omitempty"`
 }
 
 // Validate validates this job data
 func (m *JobData) Validate(formats strfmt.Registry) error {
 	var res []error
 
+	if err := m.validateCompare(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.validateConflict(formats); err != nil {
+		res = append(res, err)
+	}
+
 	if err := m.validateDrUser(formats); err != nil {
 		res = append(res, err)
 	}
@@ -65,6 +129,10 @@ func (m *JobData) Validate(formats strfmt.Registry) error {
 		res = append(res, err)
 	}
 
+	if err := m.validateMetadata(formats); err != nil {
+		res = append(res, err)
+	}
+
 	if err := m.validateSrcURL(formats); err != nil {
 		res = append(res, err)
 	}
@@ -77,6 +145,10 @@ func (m *JobData) Validate(formats strfmt.Registry) error {
 		res = append(res, err)
 	}
 
+	if err := m.validateSymlinks(formats); err != nil {
+		res = append(res, err)
+	}
+
 	if err := m.validateTitle(formats); err != nil {
 		res = append(res, err)
 	}
@@ -87,6 +159,99 @@ func (m *JobData) Validate(formats strfmt.Registry) error {
 	return nil
 }
 
+var jobDataTypeComparePropEnum []interface{}
+
+func init() {
+	var res []string
+	if err := json.Unmarshal([]byte(`["checksum","size-mtime","always"]`), &res); err != nil {
+		panic(err)
+	}
+	for _, v := range res {
+		jobDataTypeComparePropEnum = append(jobDataTypeComparePropEnum, v)
+	}
+}
+
+const (
+
+	// JobDataCompareChecksum captures enum value "checksum"
+	JobDataCompareChecksum string = "checksum"
+
+	// JobDataCompareSizeDashMtime captures enum value "size-mtime"
+	JobDataCompareSizeDashMtime string = "size-mtime"
+
+	// JobDataCompareAlways captures enum value "always"
+	JobDataCompareAlways string = "always"
+)
+
+// prop value enum
+func (m *JobData) validateCompareEnum(path, location string, value string) error {
+	if err := validate.EnumCase(path, location, value, jobDataTypeComparePropEnum, true); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (m *JobData) validateCompare(formats strfmt.Registry) error {
+	if swag.IsZero(m.Compare) { // not required
+		return nil
+	}
+
+	// value enum
+	if err := m.validateCompareEnum("compare", "body", m.Compare); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+var jobDataTypeConflictPropEnum []interface{}
+
+func init() {
+	var res []string
+	if err := json.Unmarshal([]byte(`["overwrite","skip","rename","fail"]`), &res); err != nil {
+		panic(err)
+	}
+	for _, v := range res {
+		jobDataTypeConflictPropEnum = append(jobDataTypeConflictPropEnum, v)
+	}
+}
+
+const (
+
+	// JobDataConflictOverwrite captures enum value "overwrite"
+	JobDataConflictOverwrite string = "overwrite"
+
+	// JobDataConflictSkip captures enum value "skip"
+	JobDataConflictSkip string = "skip"
+
+	// JobDataConflictRename captures enum value "rename"
+	JobDataConflictRename string = "rename"
+
+	// JobDataConflictFail captures enum value "fail"
+	JobDataConflictFail string = "fail"
+)
+
+// prop value enum
+func (m *JobData) validateConflictEnum(path, location string, value string) error {
+	if err := validate.EnumCase(path, location, value, jobDataTypeConflictPropEnum, true); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (m *JobData) validateConflict(formats strfmt.Registry) error {
+	if swag.IsZero(m.Conflict) { // not required
+		return nil
+	}
+
+	// value enum
+	if err := m.validateConflictEnum("conflict", "body", m.Conflict); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (m *JobData) validateDrUser(formats strfmt.Registry) error {
 
 	if err := validate.Required("drUser", "body", m.DrUser); err != nil {
@@ -105,6 +270,32 @@ func (m *JobData) validateDstURL(formats strfmt.Registry) error {
 	return nil
 }
 
+func (m *JobData) validateMetadata(formats strfmt.Registry) error {
+	if swag.IsZero(m.Metadata) { // not required
+		return nil
+	}
+
+	for i := 0; i < len(m.Metadata); i++ {
+		if swag.IsZero(m.Metadata[i]) { // not required
+			continue
+		}
+
+		if m.Metadata[i] != nil {
+			if err := m.Metadata[i].Validate(formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("metadata" + "." + strconv.Itoa(i))
+				} else if ce, ok := err.(*errors.CompositeError); ok {
+					return ce.ValidateName("metadata" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
+	return nil
+}
+
 func (m *JobData) validateSrcURL(formats strfmt.Registry) error {
 
 	if err := validate.Required("srcURL", "body", m.SrcURL); err != nil {
@@ -135,6 +326,51 @@ func (m *JobData) validateStagerUserEmail(formats strfmt.Registry) error {
 	return nil
 }
 
+var jobDataTypeSymlinksPropEnum []interface{}
+
+func init() {
+	var res []string
+	if err := json.Unmarshal([]byte(`["skip","follow","record"]`), &res); err != nil {
+		panic(err)
+	}
+	for _, v := range res {
+		jobDataTypeSymlinksPropEnum = append(jobDataTypeSymlinksPropEnum, v)
+	}
+}
+
+const (
+
+	// JobDataSymlinksSkip captures enum value "skip"
+	JobDataSymlinksSkip string = "skip"
+
+	// JobDataSymlinksFollow captures enum value "follow"
+	JobDataSymlinksFollow string = "follow"
+
+	// JobDataSymlinksRecord captures enum value "record"
+	JobDataSymlinksRecord string = "record"
+)
+
+// prop value enum
+func (m *JobData) validateSymlinksEnum(path, location string, value string) error {
+	if err := validate.EnumCase(path, location, value, jobDataTypeSymlinksPropEnum, true); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (m *JobData) validateSymlinks(formats strfmt.Registry) error {
+	if swag.IsZero(m.Symlinks) { // not required
+		return nil
+	}
+
+	// value enum
+	if err := m.validateSymlinksEnum("symlinks", "body", m.Symlinks); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (m *JobData) validateTitle(formats strfmt.Registry) error {
 
 	if err := validate.Required("title", "body", m.Title); err != nil {
@@ -144,8 +380,42 @@ func (m *JobData) validateTitle(formats strfmt.Registry) error {
 	return nil
 }
 
-// ContextValidate validates this job data based on context it is used
+// ContextValidate validate this job data based on the context it is used
 func (m *JobData) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.contextValidateMetadata(ctx, formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+func (m *JobData) contextValidateMetadata(ctx context.Context, formats strfmt.Registry) error {
+
+	for i := 0; i < len(m.Metadata); i++ {
+
+		if m.Metadata[i] != nil {
+
+			if swag.IsZero(m.Metadata[i]) { // not required
+				return nil
+			}
+
+			if err := m.Metadata[i].ContextValidate(ctx, formats); err != nil {
+				if ve, ok := err.(*errors.Validation); ok {
+					return ve.ValidateName("metadata" + "." + strconv.Itoa(i))
+				} else if ce, ok := err.(*errors.CompositeError); ok {
+					return ce.ValidateName("metadata" + "." + strconv.Itoa(i))
+				}
+				return err
+			}
+		}
+
+	}
+
 	return nil
 }
 
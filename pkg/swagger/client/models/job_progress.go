@@ -19,6 +19,9 @@ import (
 // swagger:model jobProgress
 type JobProgress struct {
 
+	// estimated time of completion as unix timestamp; 0 if unknown
+	Eta int64 `json:"eta,omitempty"`
+
 	// number of failed files
 	// Required: true
 	Failed *int64 `json:"failed"`
@@ -27,9 +30,21 @@ type JobProgress struct {
 	// Required: true
 	Processed *int64 `json:"processed"`
 
+	// size of processed files in bytes, including the partially transferred file
+	ProcessedBytes int64 `json:"processedBytes,omitempty"`
+
+	// the totals are provisional and still growing, as the scan of the source is in progress
+	Provisional bool `json:"provisional,omitempty"`
+
+	// current transfer rate in bytes per second
+	Rate int64 `json:"rate,omitempty"`
+
 	// number of total files to be processed
 	// Required: true
 	Total *int64 `json:"total"`
+
+	// total size of files to be processed in bytes
+	TotalBytes int64 `json:"totalBytes,omitempty"`
 }
 
 // Validate validates this job progress
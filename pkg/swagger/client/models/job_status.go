@@ -24,14 +24,29 @@ type JobStatus struct {
 	// Required: true
 	Attempts *int64 `json:"attempts"`
 
+	// numbers of files existing at the destination handled by the conflict policy
+	Conflicts *JobConflicts `json:"conflicts,omitempty"`
+
+	// number of files removed from the destination in the mirror mode
+	Deleted int64 `json:"deleted,omitempty"`
+
+	// the first paths removed from the destination in the mirror mode, all removed paths are in the transfer records
+	DeletedPaths []string `json:"deletedPaths"`
+
 	// job error message from the last execution.
 	// Required: true
 	Error *string `json:"error"`
 
+	// summary of the planned actions of a plan-only job
+	Plan *JobPlan `json:"plan,omitempty"`
+
 	// job progress info from the last execution.
 	// Required: true
 	Progress *JobProgress `json:"progress"`
 
+	// number of symbolic links in the source skipped by the symlink policy
+	SkippedLinks int64 `json:"skippedLinks,omitempty"`
+
 	// job status from the last execution.
 	// Required: true
 	// Enum: [scheduled pending active retry completed archived]
@@ -46,10 +61,18 @@ func (m *JobStatus) Validate(formats strfmt.Registry) error {
 		res = append(res, err)
 	}
 
+	if err := m.validateConflicts(formats); err != nil {
+		res = append(res, err)
+	}
+
 	if err := m.validateError(formats); err != nil {
 		res = append(res, err)
 	}
 
+	if err := m.validatePlan(formats); err != nil {
+		res = append(res, err)
+	}
+
 	if err := m.validateProgress(formats); err != nil {
 		res = append(res, err)
 	}
@@ -73,6 +96,25 @@ func (m *JobStatus) validateAttempts(formats strfmt.Registry) error {
 	return nil
 }
 
+func (m *JobStatus) validateConflicts(formats strfmt.Registry) error {
+	if swag.IsZero(m.Conflicts) { // not required
+		return nil
+	}
+
+	if m.Conflicts != nil {
+		if err := m.Conflicts.Validate(formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("conflicts")
+			} else if ce, ok := err.(*errors.CompositeError); ok {
+				return ce.ValidateName("conflicts")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (m *JobStatus) validateError(formats strfmt.Registry) error {
 
 	if err := validate.Required("error", "body", m.Error); err != nil {
@@ -82,6 +124,25 @@ func (m *JobStatus) validateError(formats strfmt.Registry) error {
 	return nil
 }
 
+func (m *JobStatus) validatePlan(formats strfmt.Registry) error {
+	if swag.IsZero(m.Plan) { // not required
+		return nil
+	}
+
+	if m.Plan != nil {
+		if err := m.Plan.Validate(formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("plan")
+			} else if ce, ok := err.(*errors.CompositeError); ok {
+				return ce.ValidateName("plan")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (m *JobStatus) validateProgress(formats strfmt.Registry) error {
 
 	if err := validate.Required("progress", "body", m.Progress); err != nil {
@@ -161,6 +222,14 @@ func (m *JobStatus) validateStatus(formats strfmt.Registry) error {
 func (m *JobStatus) ContextValidate(ctx context.Context, formats strfmt.Registry) error {
 	var res []error
 
+	if err := m.contextValidateConflicts(ctx, formats); err != nil {
+		res = append(res, err)
+	}
+
+	if err := m.contextValidatePlan(ctx, formats); err != nil {
+		res = append(res, err)
+	}
+
 	if err := m.contextValidateProgress(ctx, formats); err != nil {
 		res = append(res, err)
 	}
@@ -171,6 +240,48 @@ func (m *JobStatus) ContextValidate(ctx context.Context, formats strfmt.Registry
 	return nil
 }
 
+func (m *JobStatus) contextValidateConflicts(ctx context.Context, formats strfmt.Registry) error {
+
+	if m.Conflicts != nil {
+
+		if swag.IsZero(m.Conflicts) { // not required
+			return nil
+		}
+
+		if err := m.Conflicts.ContextValidate(ctx, formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("conflicts")
+			} else if ce, ok := err.(*errors.CompositeError); ok {
+				return ce.ValidateName("conflicts")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *JobStatus) contextValidatePlan(ctx context.Context, formats strfmt.Registry) error {
+
+	if m.Plan != nil {
+
+		if swag.IsZero(m.Plan) { // not required
+			return nil
+		}
+
+		if err := m.Plan.ContextValidate(ctx, formats); err != nil {
+			if ve, ok := err.(*errors.Validation); ok {
+				return ve.ValidateName("plan")
+			} else if ce, ok := err.(*errors.CompositeError); ok {
+				return ce.ValidateName("plan")
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (m *JobStatus) contextValidateProgress(ctx context.Context, formats strfmt.Registry) error {
 
 	if m.Progress != nil {
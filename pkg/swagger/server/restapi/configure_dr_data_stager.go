@@ -76,6 +76,11 @@ func configureAPI(api *operations.DrDataStagerAPI) http.Handler {
 			return middleware.NotImplemented("operation operations.GetJobID has not yet been implemented")
 		})
 	}
+	if api.GetJobIDFilesHandler == nil {
+		api.GetJobIDFilesHandler = operations.GetJobIDFilesHandlerFunc(func(params operations.GetJobIDFilesParams, principal *models.Principal) middleware.Responder {
+			return middleware.NotImplemented("operation operations.GetJobIDFiles has not yet been implemented")
+		})
+	}
 	if api.GetJobsHandler == nil {
 		api.GetJobsHandler = operations.GetJobsHandlerFunc(func(params operations.GetJobsParams, principal *models.Principal) middleware.Responder {
 			return middleware.NotImplemented("operation operations.GetJobs has not yet been implemented")
@@ -324,6 +324,87 @@ func init() {
         }
       }
     },
+    "/job/{id}/files": {
+      "get": {
+        "security": [
+          {
+            "oauth2": [
+              "urn:dccn:data-stager-api:*"
+            ]
+          },
+          {
+            "basicAuth": []
+          }
+        ],
+        "consumes": [
+          "application/json"
+        ],
+        "produces": [
+          "application/json"
+        ],
+        "summary": "get transfer records of files processed by a stager job",
+        "parameters": [
+          {
+            "type": "string",
+            "description": "job identifier",
+            "name": "id",
+            "in": "path",
+            "required": true
+          },
+          {
+            "enum": [
+              "copied",
+              "skipped",
+              "failed",
+              "deleted",
+              "copy",
+              "overwrite",
+              "skip",
+              "delete"
+            ],
+            "type": "string",
+            "description": "only return file records with the given action",
+            "name": "status",
+            "in": "query"
+          },
+          {
+            "type": "integer",
+            "description": "number of file records to skip",
+            "name": "offset",
+            "in": "query"
+          },
+          {
+            "type": "integer",
+            "description": "maximum number of file records to return",
+            "name": "limit",
+            "in": "query"
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "success",
+            "schema": {
+              "$ref": "#/definitions/responseBodyJobFiles"
+            }
+          },
+          "404": {
+            "description": "job not found",
+            "schema": {
+              "type": "string",
+              "enum": [
+                "job not found"
+              ]
+            }
+          },
+          "500": {
+            "description": "failure",
+            "schema": {
+              "$ref": "#/definitions/responseBody500"
+            }
+          }
+        }
+      }
+    },
     "/jobs": {
       "get": {
         "security": [
@@ -543,6 +624,33 @@ func init() {
         }
       }
     },
+    "jobConflicts": {
+      "description": "numbers of files existing at the destination that differ from the source, by the action of the conflict policy",
+      "required": [
+        "overwritten",
+        "skipped",
+        "renamed",
+        "failed"
+      ],
+      "properties": {
+        "failed": {
+          "description": "number of files failed as they exist at the destination",
+          "type": "integer"
+        },
+        "overwritten": {
+          "description": "number of files at the destination overwritten",
+          "type": "integer"
+        },
+        "renamed": {
+          "description": "number of files at the destination renamed with a timestamp suffix",
+          "type": "integer"
+        },
+        "skipped": {
+          "description": "number of files not transferred as they exist at the destination",
+          "type": "integer"
+        }
+      }
+    },
     "jobData": {
       "description": "job data",
       "required": [
@@ -553,6 +661,41 @@ func init() {
         "dstURL"
       ],
       "properties": {
+        "bandwidthLimit": {
+          "description": "bandwidth limit of the transfer in bytes per second (0 for no limit); the transfer is further limited by the bandwidth cap of the worker shared by concurrent jobs",
+          "type": "integer"
+        },
+        "bundleSize": {
+          "description": "maximum size in bytes of the files in a tar bundle (0 for the default of 256 MiB)",
+          "type": "integer"
+        },
+        "bundleThreshold": {
+          "description": "upload files smaller than the size in bytes in tar bundles, grouped by directory (0 for no bundling)",
+          "type": "integer"
+        },
+        "compare": {
+          "description": "strategy to detect identical files that are skipped; checksum (default) compares the sizes and checksums, size-mtime compares the sizes and modification times without reading the files, always transfers all files",
+          "type": "string",
+          "enum": [
+            "checksum",
+            "size-mtime",
+            "always"
+          ]
+        },
+        "conflict": {
+          "description": "handling of files existing at the destination that differ from the source; overwrite (default) overwrites them, skip keeps them without transferring the source files, rename keeps them by renaming them with a timestamp suffix, fail keeps them and counts the source files as failed",
+          "type": "string",
+          "enum": [
+            "overwrite",
+            "skip",
+            "rename",
+            "fail"
+          ]
+        },
+        "continueOnError": {
+          "description": "continue with remaining files when a file fails to be transferred; the job is then completed with failures instead of being retried",
+          "type": "boolean"
+        },
         "drPass": {
           "description": "password of the DR data-access account",
           "type": "string"
@@ -565,6 +708,47 @@ func init() {
           "description": "path or DR namespace (prefixed with irods:) of the destination endpoint",
           "type": "string"
         },
+        "exclude": {
+          "description": "skip files and directories matching one of the glob patterns, e.g. *.tmp or scratch/",
+          "type": "array",
+          "items": {
+            "type": "string"
+          }
+        },
+        "include": {
+          "description": "only transfer files matching one of the glob patterns, e.g. *.nii.gz",
+          "type": "array",
+          "items": {
+            "type": "string"
+          }
+        },
+        "maxDeletions": {
+          "description": "maximum number of files allowed to be removed in the mirror mode (0 for the default of 1000); nothing is removed if the limit is exceeded",
+          "type": "integer"
+        },
+        "maxSize": {
+          "description": "skip files larger than the size in bytes (0 for no limit)",
+          "type": "integer"
+        },
+        "metadata": {
+          "description": "iRODS metadata attached to the uploaded data objects and the top-level collection",
+          "type": "array",
+          "items": {
+            "$ref": "#/definitions/jobMetadata"
+          }
+        },
+        "minSize": {
+          "description": "skip files smaller than the size in bytes",
+          "type": "integer"
+        },
+        "mirror": {
+          "description": "remove files at the destination that are not present at the source, after a successful transfer",
+          "type": "boolean"
+        },
+        "planOnly": {
+          "description": "only plan the transfer without moving data; the job status reports the numbers of files to be copied, overwritten, skipped or removed, and the total size to be transferred",
+          "type": "boolean"
+        },
         "srcURL": {
           "description": "path or DR namespace (prefixed with irods:) of the source endpoint",
           "type": "string"
@@ -578,6 +762,15 @@ func init() {
           "type": "string",
           "format": "email"
         },
+        "symlinks": {
+          "description": "handling of symbolic links in a local source directory; skip (default) skips the links and reports them in the job status, follow transfers the files the links refer to, record creates empty placeholder data objects with the link targets recorded as metadata",
+          "type": "string",
+          "enum": [
+            "skip",
+            "follow",
+            "record"
+          ]
+        },
         "timeout": {
           "description": "allowed duration in seconds for entire transfer job (0 for no timeout)",
           "type": "integer"
@@ -589,6 +782,61 @@ func init() {
         "title": {
           "description": "short description about the job",
           "type": "string"
+        },
+        "unpack": {
+          "description": "unpack the tar bundles into the original files when downloading from iRODS",
+          "type": "boolean"
+        },
+        "verify": {
+          "description": "verify each transferred file by comparing the checksum of the source and the destination; a mismatch is counted as a failed file",
+          "type": "boolean"
+        }
+      }
+    },
+    "jobFile": {
+      "description": "transfer record of a file processed by the job",
+      "required": [
+        "path",
+        "action"
+      ],
+      "properties": {
+        "action": {
+          "description": "action taken on the file, or planned on the file by a plan-only job",
+          "type": "string",
+          "enum": [
+            "copied",
+            "skipped",
+            "failed",
+            "deleted",
+            "copy",
+            "overwrite",
+            "skip",
+            "delete"
+          ]
+        },
+        "checksum": {
+          "description": "checksum of the file in the form of <algorithm>:<hex digest>",
+          "type": "string"
+        },
+        "dstPath": {
+          "description": "destination path of the file",
+          "type": "string"
+        },
+        "error": {
+          "description": "error message of the failed file",
+          "type": "string"
+        },
+        "link": {
+          "description": "target of the symbolic link, if the file is a link not being followed",
+          "type": "string"
+        },
+        "path": {
+          "description": "source path of the file",
+          "type": "string"
+        },
+        "size": {
+          "description": "size of the file in bytes",
+          "type": "integer"
         }
       }
     },
@@ -619,6 +867,66 @@ func init() {
         }
       }
     },
+    "jobMetadata": {
+      "description": "iRODS attribute-value-unit triple; the value and the unit are Go templates rendered with the fields Path (path relative to the top-level collection), Name, Size (in bytes) and ModTime of the data object or collection, e.g. {{.Path}}",
+      "required": [
+        "attribute",
+        "value"
+      ],
+      "properties": {
+        "attribute": {
+          "description": "name of the metadata attribute",
+          "type": "string"
+        },
+        "unit": {
+          "description": "unit of the metadata attribute, or a template of it",
+          "type": "string"
+        },
+        "value": {
+          "description": "value of the metadata attribute, or a template of it",
+          "type": "string"
+        }
+      }
+    },
+    "jobPlan": {
+      "description": "summary of the actions planned by a plan-only job",
+      "required": [
+        "copy",
+        "overwrite",
+        "skip",
+        "delete",
+        "bytes"
+      ],
+      "properties": {
+        "bytes": {
+          "description": "total size in bytes of the files to be copied or overwritten",
+          "type": "integer"
+        },
+        "copy": {
+          "description": "number of files to be copied to the destination",
+          "type": "integer"
+        },
+        "delete": {
+          "description": "number of files to be removed from the destination in the mirror mode",
+          "type": "integer"
+        },
+        "overwrite": {
+          "description": "number of files at the destination to be overwritten",
+          "type": "integer"
+        },
+        "sample": {
+          "description": "a sample of the planned actions",
+          "type": "array",
+          "items": {
+            "$ref": "#/definitions/jobFile"
+          }
+        },
+        "skip": {
+          "description": "number of files skipped as they are identical at the destination",
+          "type": "integer"
+        }
+      }
+    },
     "jobProgress": {
       "description": "job progress information",
       "required": [
@@ -627,6 +935,10 @@ func init() {
         "failed"
       ],
       "properties": {
+        "eta": {
+          "description": "estimated time of completion as unix timestamp; 0 if unknown",
+          "type": "integer"
+        },
         "failed": {
           "description": "number of failed files",
           "type": "integer"
@@ -635,9 +947,25 @@ func init() {
           "description": "number of processed files",
           "type": "integer"
         },
+        "processedBytes": {
+          "description": "size of processed files in bytes, including the partially transferred file",
+          "type": "integer"
+        },
+        "provisional": {
+          "description": "the totals are provisional and still growing, as the scan of the source is in progress",
+          "type": "boolean"
+        },
+        "rate": {
+          "description": "current transfer rate in bytes per second",
+          "type": "integer"
+        },
         "total": {
           "description": "number of total files to be processed",
           "type": "integer"
+        },
+        "totalBytes": {
+          "description": "total size of files to be processed in bytes",
+          "type": "integer"
         }
       }
     },
@@ -654,14 +982,37 @@ func init() {
           "description": "number of attempts",
           "type": "integer"
         },
+        "conflicts": {
+          "description": "numbers of files existing at the destination handled by the conflict policy",
+          "$ref": "#/definitions/jobConflicts"
+        },
+        "deleted": {
+          "description": "number of files removed from the destination in the mirror mode",
+          "type": "integer"
+        },
+        "deletedPaths": {
+          "description": "the first paths removed from the destination in the mirror mode, all removed paths are in the transfer records",
+          "type": "array",
+          "items": {
+            "type": "string"
+          }
+        },
         "error": {
           "description": "job error message from the last execution.",
           "type": "string"
         },
+        "plan": {
+          "description": "summary of the planned actions of a plan-only job",
+          "$ref": "#/definitions/jobPlan"
+        },
         "progress": {
           "description": "job progress info from the last execution.",
           "$ref": "#/definitions/jobProgress"
         },
+        "skippedLinks": {
+          "description": "number of symbolic links in the source skipped by the symlink policy",
+          "type": "integer"
+        },
         "status": {
           "description": "job status from the last execution.",
           "type": "string",
@@ -731,6 +1082,21 @@ func init() {
         }
       }
     },
+    "responseBodyJobFiles": {
+      "description": "JSON object containing a list of file transfer records of a job.",
+      "properties": {
+        "files": {
+          "type": "array",
+          "items": {
+            "$ref": "#/definitions/jobFile"
+          }
+        },
+        "total": {
+          "description": "number of file records matching the query",
+          "type": "integer"
+        }
+      }
+    },
     "responseBodyJobs": {
       "description": "JSON object containing a list of job information.",
       "properties": {
@@ -1075,6 +1441,87 @@ func init() {
         }
       }
     },
+    "/job/{id}/files": {
+      "get": {
+        "security": [
+          {
+            "oauth2": [
+              "urn:dccn:data-stager-api:*"
+            ]
+          },
+          {
+            "basicAuth": []
+          }
+        ],
+        "consumes": [
+          "application/json"
+        ],
+        "produces": [
+          "application/json"
+        ],
+        "summary": "get transfer records of files processed by a stager job",
+        "parameters": [
+          {
+            "type": "string",
+            "description": "job identifier",
+            "name": "id",
+            "in": "path",
+            "required": true
+          },
+          {
+            "enum": [
+              "copied",
+              "skipped",
+              "failed",
+              "deleted",
+              "copy",
+              "overwrite",
+              "skip",
+              "delete"
+            ],
+            "type": "string",
+            "description": "only return file records with the given action",
+            "name": "status",
+            "in": "query"
+          },
+          {
+            "type": "integer",
+            "description": "number of file records to skip",
+            "name": "offset",
+            "in": "query"
+          },
+          {
+            "type": "integer",
+            "description": "maximum number of file records to return",
+            "name": "limit",
+            "in": "query"
+          }
+        ],
+        "responses": {
+          "200": {
+            "description": "success",
+            "schema": {
+              "$ref": "#/definitions/responseBodyJobFiles"
+            }
+          },
+          "404": {
+            "description": "job not found",
+            "schema": {
+              "type": "string",
+              "enum": [
+                "job not found"
+              ]
+            }
+          },
+          "500": {
+            "description": "failure",
+            "schema": {
+              "$ref": "#/definitions/responseBody500"
+            }
+          }
+        }
+      }
+    },
     "/jobs": {
       "get": {
         "security": [
@@ -1294,6 +1741,33 @@ func init() {
         }
       }
     },
+    "jobConflicts": {
+      "description": "numbers of files existing at the destination that differ from the source, by the action of the conflict policy",
+      "required": [
+        "overwritten",
+        "skipped",
+        "renamed",
+        "failed"
+      ],
+      "properties": {
+        "failed": {
+          "description": "number of files failed as they exist at the destination",
+          "type": "integer"
+        },
+        "overwritten": {
+          "description": "number of files at the destination overwritten",
+          "type": "integer"
+        },
+        "renamed": {
+          "description": "number of files at the destination renamed with a timestamp suffix",
+          "type": "integer"
+        },
+        "skipped": {
+          "description": "number of files not transferred as they exist at the destination",
+          "type": "integer"
+        }
+      }
+    },
     "jobData": {
       "description": "job data",
       "required": [
@@ -1304,6 +1778,41 @@ func init() {
         "dstURL"
       ],
       "properties": {
+        "bandwidthLimit": {
+          "description": "bandwidth limit of the transfer in bytes per second (0 for no limit); the transfer is further limited by the bandwidth cap of the worker shared by concurrent jobs",
+          "type": "integer"
+        },
+        "bundleSize": {
+          "description": "maximum size in bytes of the files in a tar bundle (0 for the default of 256 MiB)",
+          "type": "integer"
+        },
+        "bundleThreshold": {
+          "description": "upload files smaller than the size in bytes in tar bundles, grouped by directory (0 for no bundling)",
+          "type": "integer"
+        },
+        "compare": {
+          "description": "strategy to detect identical files that are skipped; checksum (default) compares the sizes and checksums, size-mtime compares the sizes and modification times without reading the files, always transfers all files",
+          "type": "string",
+          "enum": [
+            "checksum",
+            "size-mtime",
+            "always"
+          ]
+        },
+        "conflict": {
+          "description": "handling of files existing at the destination that differ from the source; overwrite (default) overwrites them, skip keeps them without transferring the source files, rename keeps them by renaming them with a timestamp suffix, fail keeps them and counts the source files as failed",
+          "type": "string",
+          "enum": [
+            "overwrite",
+            "skip",
+            "rename",
+            "fail"
+          ]
+        },
+        "continueOnError": {
+          "description": "continue with remaining files when a file fails to be transferred; the job is then completed with failures instead of being retried",
+          "type": "boolean"
+        },
         "drPass": {
           "description": "password of the DR data-access account",
           "type": "string"
@@ -1316,6 +1825,47 @@ func init() {
           "description": "path or DR namespace (prefixed with irods:) of the destination endpoint",
           "type": "string"
         },
+        "exclude": {
+          "description": "skip files and directories matching one of the glob patterns, e.g. *.tmp or scratch/",
+          "type": "array",
+          "items": {
+            "type": "string"
+          }
+        },
+        "include": {
+          "description": "only transfer files matching one of the glob patterns, e.g. *.nii.gz",
+          "type": "array",
+          "items": {
+            "type": "string"
+          }
+        },
+        "maxDeletions": {
+          "description": "maximum number of files allowed to be removed in the mirror mode (0 for the default of 1000); nothing is removed if the limit is exceeded",
+          "type": "integer"
+        },
+        "maxSize": {
+          "description": "skip files larger than the size in bytes (0 for no limit)",
+          "type": "integer"
+        },
+        "metadata": {
+          "description": "iRODS metadata attached to the uploaded data objects and the top-level collection",
+          "type": "array",
+          "items": {
+            "$ref": "#/definitions/jobMetadata"
+          }
+        },
+        "minSize": {
+          "description": "skip files smaller than the size in bytes",
+          "type": "integer"
+        },
+        "mirror": {
+          "description": "remove files at the destination that are not present at the source, after a successful transfer",
+          "type": "boolean"
+        },
+        "planOnly": {
+          "description": "only plan the transfer without moving data; the job status reports the numbers of files to be copied, overwritten, skipped or removed, and the total size to be transferred",
+          "type": "boolean"
+        },
         "srcURL": {
           "description": "path or DR namespace (prefixed with irods:) of the source endpoint",
           "type": "string"
@@ -1329,6 +1879,15 @@ func init() {
           "type": "string",
           "format": "email"
         },
+        "symlinks": {
+          "description": "handling of symbolic links in a local source directory; skip (default) skips the links and reports them in the job status, follow transfers the files the links refer to, record creates empty placeholder data objects with the link targets recorded as metadata",
+          "type": "string",
+          "enum": [
+            "skip",
+            "follow",
+            "record"
+          ]
+        },
         "timeout": {
           "description": "allowed duration in seconds for entire transfer job (0 for no timeout)",
           "type": "integer"
@@ -1340,6 +1899,61 @@ func init() {
         "title": {
           "description": "short description about the job",
           "type": "string"
+        },
+        "unpack": {
+          "description": "unpack the tar bundles into the original files when downloading from iRODS",
+          "type": "boolean"
+        },
+        "verify": {
+          "description": "verify each transferred file by comparing the checksum of the source and the destination; a mismatch is counted as a failed file",
+          "type": "boolean"
+        }
+      }
+    },
+    "jobFile": {
+      "description": "transfer record of a file processed by the job",
+      "required": [
+        "path",
+        "action"
+      ],
+      "properties": {
+        "action": {
+          "description": "action taken on the file, or planned on the file by a plan-only job",
+          "type": "string",
+          "enum": [
+            "copied",
+            "skipped",
+            "failed",
+            "deleted",
+            "copy",
+            "overwrite",
+            "skip",
+            "delete"
+          ]
+        },
+        "checksum": {
+          "description": "checksum of the file in the form of <algorithm>:<hex digest>",
+          "type": "string"
+        },
+        "dstPath": {
+          "description": "destination path of the file",
+          "type": "string"
+        },
+        "error": {
+          "description": "error message of the failed file",
+          "type": "string"
+        },
+        "link": {
+          "description": "target of the symbolic link, if the file is a link not being followed",
+          "type": "string"
+        },
+        "path": {
+          "description": "source path of the file",
+          "type": "string"
+        },
+        "size": {
+          "description": "size of the file in bytes",
+          "type": "integer"
         }
       }
     },
@@ -1370,6 +1984,66 @@ func init() {
         }
       }
     },
+    "jobMetadata": {
+      "description": "iRODS attribute-value-unit triple; the value and the unit are Go templates rendered with the fields Path (path relative to the top-level collection), Name, Size (in bytes) and ModTime of the data object or collection, e.g. {{.Path}}",
+      "required": [
+        "attribute",
+        "value"
+      ],
+      "properties": {
+        "attribute": {
+          "description": "name of the metadata attribute",
+          "type": "string"
+        },
+        "unit": {
+          "description": "unit of the metadata attribute, or a template of it",
+          "type": "string"
+        },
+        "value": {
+          "description": "value of the metadata attribute, or a template of it",
+          "type": "string"
+        }
+      }
+    },
+    "jobPlan": {
+      "description": "summary of the actions planned by a plan-only job",
+      "required": [
+        "copy",
+        "overwrite",
+        "skip",
+        "delete",
+        "bytes"
+      ],
+      "properties": {
+        "bytes": {
+          "description": "total size in bytes of the files to be copied or overwritten",
+          "type": "integer"
+        },
+        "copy": {
+          "description": "number of files to be copied to the destination",
+          "type": "integer"
+        },
+        "delete": {
+          "description": "number of files to be removed from the destination in the mirror mode",
+          "type": "integer"
+        },
+        "overwrite": {
+          "description": "number of files at the destination to be overwritten",
+          "type": "integer"
+        },
+        "sample": {
+          "description": "a sample of the planned actions",
+          "type": "array",
+          "items": {
+            "$ref": "#/definitions/jobFile"
+          }
+        },
+        "skip": {
+          "description": "number of files skipped as they are identical at the destination",
+          "type": "integer"
+        }
+      }
+    },
     "jobProgress": {
       "description": "job progress information",
       "required": [
@@ -1378,6 +2052,10 @@ func init() {
         "failed"
       ],
       "properties": {
+        "eta": {
+          "description": "estimated time of completion as unix timestamp; 0 if unknown",
+          "type": "integer"
+        },
         "failed": {
           "description": "number of failed files",
           "type": "integer"
@@ -1386,9 +2064,25 @@ func init() {
           "description": "number of processed files",
           "type": "integer"
         },
+        "processedBytes": {
+          "description": "size of processed files in bytes, including the partially transferred file",
+          "type": "integer"
+        },
+        "provisional": {
+          "description": "the totals are provisional and still growing, as the scan of the source is in progress",
+          "type": "boolean"
+        },
+        "rate": {
+          "description": "current transfer rate in bytes per second",
+          "type": "integer"
+        },
         "total": {
           "description": "number of total files to be processed",
           "type": "integer"
+        },
+        "totalBytes": {
+          "description": "total size of files to be processed in bytes",
+          "type": "integer"
         }
       }
     },
@@ -1405,14 +2099,37 @@ func init() {
           "description": "number of attempts",
           "type": "integer"
         },
+        "conflicts": {
+          "description": "numbers of files existing at the destination handled by the conflict policy",
+          "$ref": "#/definitions/jobConflicts"
+        },
+        "deleted": {
+          "description": "number of files removed from the destination in the mirror mode",
+          "type": "integer"
+        },
+        "deletedPaths": {
+          "description": "the first paths removed from the destination in the mirror mode, all removed paths are in the transfer records",
+          "type": "array",
+          "items": {
+            "type": "string"
+          }
+        },
         "error": {
           "description": "job error message from the last execution.",
           "type": "string"
         },
+        "plan": {
+          "description": "summary of the planned actions of a plan-only job",
+          "$ref": "#/definitions/jobPlan"
+        },
         "progress": {
           "description": "job progress info from the last execution.",
           "$ref": "#/definitions/jobProgress"
         },
+        "skippedLinks": {
+          "description": "number of symbolic links in the source skipped by the symlink policy",
+          "type": "integer"
+        },
         "status": {
           "description": "job status from the last execution.",
           "type": "string",
@@ -1482,6 +2199,21 @@ func init() {
         }
       }
     },
+    "responseBodyJobFiles": {
+      "description": "JSON object containing a list of file transfer records of a job.",
+      "properties": {
+        "files": {
+          "type": "array",
+          "items": {
+            "$ref": "#/definitions/jobFile"
+          }
+        },
+        "total": {
+          "description": "number of file records matching the query",
+          "type": "integer"
+        }
+      }
+    },
     "responseBodyJobs": {
       "description": "JSON object containing a list of job information.",
       "properties": {
@@ -56,6 +56,9 @@ func NewDrDataStagerAPI(spec *loads.Document) *DrDataStagerAPI {
 		GetJobIDHandler: GetJobIDHandlerFunc(func(params GetJobIDParams, principal *models.Principal) middleware.Responder {
 			return middleware.NotImplemented("operation GetJobID has not yet been implemented")
 		}),
+		GetJobIDFilesHandler: GetJobIDFilesHandlerFunc(func(params GetJobIDFilesParams, principal *models.Principal) middleware.Responder {
+			return middleware.NotImplemented("operation GetJobIDFiles has not yet been implemented")
+		}),
 		GetJobsHandler: GetJobsHandlerFunc(func(params GetJobsParams, principal *models.Principal) middleware.Responder {
 			return middleware.NotImplemented("operation GetJobs has not yet been implemented")
 		}),
@@ -139,6 +142,8 @@ type DrDataStagerAPI struct {
 	GetDirHandler GetDirHandler
 	// GetJobIDHandler sets the operation handler for the get job ID operation
 	GetJobIDHandler GetJobIDHandler
+	// GetJobIDFilesHandler sets the operation handler for the get job ID files operation
+	GetJobIDFilesHandler GetJobIDFilesHandler
 	// GetJobsHandler sets the operation handler for the get jobs operation
 	GetJobsHandler GetJobsHandler
 	// GetJobsStatusHandler sets the operation handler for the get jobs status operation
@@ -247,6 +252,9 @@ func (o *DrDataStagerAPI) Validate() error {
 	if o.GetJobIDHandler == nil {
 		unregistered = append(unregistered, "GetJobIDHandler")
 	}
+	if o.GetJobIDFilesHandler == nil {
+		unregistered = append(unregistered, "GetJobIDFilesHandler")
+	}
 	if o.GetJobsHandler == nil {
 		unregistered = append(unregistered, "GetJobsHandler")
 	}
@@ -387,6 +395,10 @@ func (o *DrDataStagerAPI) initHandlerCache() {
 	if o.handlers["GET"] == nil {
 		o.handlers["GET"] = make(map[string]http.Handler)
 	}
+	o.handlers["GET"]["/job/{id}/files"] = NewGetJobIDFiles(o.context, o.GetJobIDFilesHandler)
+	if o.handlers["GET"] == nil {
+		o.handlers["GET"] = make(map[string]http.Handler)
+	}
 	o.handlers["GET"]["/jobs"] = NewGetJobs(o.context, o.GetJobsHandler)
 	if o.handlers["GET"] == nil {
 		o.handlers["GET"] = make(map[string]http.Handler)
@@ -0,0 +1,113 @@
+package tasks
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	log "github.com/dccn-tg/tg-toolset-golang/pkg/logger"
+)
+
+// bandwidthShare divides the bandwidth cap of the worker among the concurrent s-isync
+// processes on the worker host.  Each process receives its bandwidth limit in bytes per
+// second as a line on its stdin, and the limits are recalculated whenever a process joins
+// or leaves.
+type bandwidthShare struct {
+	cap   int64
+	mu    sync.Mutex
+	procs map[string]*bandwidthProc
+}
+
+// bandwidthProc is a s-isync process sharing the bandwidth of the worker.
+type bandwidthProc struct {
+	// bandwidth limit of the job; 0 for no limit
+	limit int64
+	// bandwidth limit last sent to the process; 0 for no limit
+	share int64
+	w     *os.File
+}
+
+// newBandwidthShare creates a bandwidthShare with the bandwidth `cap` in bytes per second;
+// 0 for no cap.
+func newBandwidthShare(cap int64) *bandwidthShare {
+	return &bandwidthShare{
+		cap:   cap,
+		procs: make(map[string]*bandwidthProc),
+	}
+}
+
+// Join adds the s-isync process of the task `tid` with the bandwidth `limit` of the job,
+// and returns the file to be used as the stdin of the process together with the initial
+// bandwidth limit of the process, to be given to the process at its start.  The caller
+// closes the returned file after the process is started.
+func (s *bandwidthShare) Join(tid string, limit int64) (*os.File, int64, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, 0, fmt.Errorf("fail to create bandwidth control pipe: %s", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := &bandwidthProc{limit: limit, w: w}
+	s.procs[tid] = p
+	s.rebalance()
+
+	return r, p.share, nil
+}
+
+// Leave removes the s-isync process of the task `tid`, and gives its share of the bandwidth
+// to the remaining processes.
+func (s *bandwidthShare) Leave(tid string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if p, ok := s.procs[tid]; ok {
+		p.w.Close()
+		delete(s.procs, tid)
+	}
+	s.rebalance()
+}
+
+// rebalance sends every process its bandwidth limit.  A process with a job limit below the
+// fair share keeps the job limit, the rest of the cap is divided equally among the other
+// processes.
+func (s *bandwidthShare) rebalance() {
+
+	tids := make([]string, 0, len(s.procs))
+	for tid := range s.procs {
+		tids = append(tids, tid)
+	}
+
+	// processes in the order of the job limit, the ones without limit at last.
+	sort.Slice(tids, func(i, j int) bool {
+		li, lj := s.procs[tids[i]].limit, s.procs[tids[j]].limit
+		if li <= 0 || lj <= 0 {
+			return lj <= 0 && li > 0
+		}
+		return li < lj
+	})
+
+	remain := s.cap
+	for i, tid := range tids {
+		p := s.procs[tid]
+
+		limit := p.limit
+		if s.cap > 0 {
+			share := remain / int64(len(tids)-i)
+			if share < 1 {
+				share = 1
+			}
+			if limit <= 0 || limit > share {
+				limit = share
+			}
+			remain -= limit
+		}
+		p.share = limit
+
+		if _, err := fmt.Fprintf(p.w, "%d\n", limit); err != nil {
+			log.Warnf("[%s] fail to send bandwidth limit: %s", tid, err)
+		}
+	}
+}
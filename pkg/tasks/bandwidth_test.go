@@ -0,0 +1,67 @@
+package tasks
+
+import (
+	"bufio"
+	"strconv"
+	"testing"
+)
+
+func TestBandwidthShare(t *testing.T) {
+
+	s := newBandwidthShare(1000)
+
+	// the last bandwidth limit received by each process
+	readLimit := func(r *bufio.Reader) int64 {
+		var limit int64
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				t.Fatalf("%s\n", err)
+			}
+			limit, _ = strconv.ParseInt(line[:len(line)-1], 10, 64)
+			if r.Buffered() == 0 {
+				return limit
+			}
+		}
+	}
+
+	join := func(tid string, limit, expected int64) *bufio.Reader {
+		r, share, err := s.Join(tid, limit)
+		if err != nil {
+			t.Fatalf("%s\n", err)
+		}
+		t.Cleanup(func() { r.Close() })
+		if share != expected {
+			t.Errorf("%s: expect initial limit %d, got %d", tid, expected, share)
+		}
+		return bufio.NewReader(r)
+	}
+
+	r1 := join("t1", 0, 1000)
+	if l := readLimit(r1); l != 1000 {
+		t.Errorf("t1: expect 1000, got %d", l)
+	}
+
+	r2 := join("t2", 100, 100)
+	r3 := join("t3", 0, 450)
+
+	expected := map[string]int64{"t1": 450, "t2": 100, "t3": 450}
+	for tid, r := range map[string]*bufio.Reader{"t1": r1, "t2": r2, "t3": r3} {
+		if l := readLimit(r); l != expected[tid] {
+			t.Errorf("%s: expect %d, got %d", tid, expected[tid], l)
+		}
+	}
+
+	s.Leave("t2")
+	for tid, r := range map[string]*bufio.Reader{"t1": r1, "t3": r3} {
+		if l := readLimit(r); l != 500 {
+			t.Errorf("%s: expect 500, got %d", tid, l)
+		}
+	}
+
+	s.Leave("t1")
+	s.Leave("t3")
+	if _, err := r1.ReadString('\n'); err == nil {
+		t.Errorf("t1: expect closed bandwidth control pipe")
+	}
+}
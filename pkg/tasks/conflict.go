@@ -0,0 +1,47 @@
+package tasks
+
+import (
+	"github.com/dccn-tg/dr-data-stager/pkg/event"
+	ppath "github.com/dccn-tg/dr-data-stager/pkg/path"
+)
+
+// ConflictResult counts the files that exist at the destination and differ from the source,
+// by the action taken according to the conflict policy of the task.
+type ConflictResult struct {
+	// number of files at the destination overwritten
+	Overwritten int64 `json:"overwritten"`
+
+	// number of files not transferred as they exist at the destination
+	Skipped int64 `json:"skipped"`
+
+	// number of files at the destination renamed with a timestamp suffix
+	Renamed int64 `json:"renamed"`
+
+	// number of files failed as they exist at the destination
+	Failed int64 `json:"failed"`
+}
+
+// add counts the file reported by `s-isync` with the event `evt`, if the conflict policy
+// has been applied to it.  A file failed for another reason than the conflict is not counted.
+func (r *ConflictResult) add(evt event.Event) {
+	policy := ppath.ConflictPolicy(evt.Conflict)
+	if policy == "" || (evt.Type == event.TypeFileError && policy != ppath.ConflictFail) {
+		return
+	}
+
+	switch policy {
+	case ppath.ConflictOverwrite:
+		r.Overwritten++
+	case ppath.ConflictSkip:
+		r.Skipped++
+	case ppath.ConflictRename:
+		r.Renamed++
+	case ppath.ConflictFail:
+		r.Failed++
+	}
+}
+
+// Total returns the number of files handled by the conflict policy.
+func (r ConflictResult) Total() int64 {
+	return r.Overwritten + r.Skipped + r.Renamed + r.Failed
+}
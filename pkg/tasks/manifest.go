@@ -0,0 +1,199 @@
+package tasks
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	log "github.com/dccn-tg/tg-toolset-golang/pkg/logger"
+)
+
+// Actions taken on a file, recorded in the transfer manifest.
+const (
+	ActionCopied  = "copied"
+	ActionSkipped = "skipped"
+	ActionFailed  = "failed"
+	ActionDeleted = "deleted"
+)
+
+// DefaultRetention is the default duration for which a finished task and its transfer manifest
+// are kept.
+const DefaultRetention = 2 * 24 * time.Hour
+
+// ManifestEntry is the transfer record of a file processed by `s-isync`.
+type ManifestEntry struct {
+	// path of the file at the source endpoint
+	Path string `json:"path"`
+
+	// path of the file at the destination endpoint
+	DstPath string `json:"dstPath,omitempty"`
+
+	// size of the file in bytes
+	Size int64 `json:"size"`
+
+	// checksum of the file in the form of <algorithm>:<hex digest>
+	Checksum string `json:"checksum,omitempty"`
+
+	// target of the symbolic link, if the file is a link not being followed
+	Link string `json:"link,omitempty"`
+
+	// action taken on the file
+	Action string `json:"action"`
+
+	// error message of a failed file
+	Error string `json:"error,omitempty"`
+}
+
+// ManifestKey returns the redis key of the list in which the transfer manifest of task `tid` is stored.
+func ManifestKey(tid string) string {
+	return fmt.Sprintf("stager:manifest:%s", tid)
+}
+
+// manifestFile returns the path of the JSON-lines file to which `s-isync` writes the
+// transfer manifest of task `tid`.
+func manifestFile(tid string) string {
+	return fmt.Sprintf("/tmp/s-isync-%s.manifest", tid)
+}
+
+// manifestStore pushes the transfer manifest written by `s-isync` to the file `fpath` into the
+// redis list of task `tid`, so that the records of the processed files are available while the
+// task is running.  The entries of a previous attempt are replaced.
+//
+// A `nil` store is valid; it discards the manifest.
+type manifestStore struct {
+	mu        sync.Mutex
+	rdb       *redis.Client
+	tid       string
+	fpath     string
+	retention time.Duration
+	cleared   bool
+	f         *os.File
+	r         *bufio.Reader
+	partial   []byte
+}
+
+// newManifestStore returns the store of the manifest file `fpath` of task `tid`, the redis list
+// expires `retention` after the last update.  It returns a `nil` store if `rdb` is `nil`.
+func newManifestStore(rdb *redis.Client, tid, fpath string, retention time.Duration) *manifestStore {
+	if rdb == nil {
+		return nil
+	}
+	return &manifestStore{
+		rdb:       rdb,
+		tid:       tid,
+		fpath:     fpath,
+		retention: retention,
+	}
+}
+
+// Sync pushes the entries appended to the manifest file since the last call.
+func (m *manifestStore) Sync() error {
+	if m == nil {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.sync()
+}
+
+// Close pushes the remaining entries, and removes the manifest file.  An incomplete last entry,
+// e.g. when s-isync is killed while writing, is skipped.
+func (m *manifestStore) Close() error {
+	if m == nil {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	err := m.sync()
+	if m.f != nil {
+		m.f.Close()
+	}
+	os.Remove(m.fpath)
+
+	return err
+}
+
+func (m *manifestStore) sync() error {
+
+	// the task context may be canceled already
+	ctx := context.Background()
+	key := ManifestKey(m.tid)
+
+	if !m.cleared {
+		if err := m.rdb.Del(ctx, key).Err(); err != nil {
+			return err
+		}
+		m.cleared = true
+	}
+
+	// the file is not yet created by s-isync
+	if m.f == nil {
+		f, err := os.Open(m.fpath)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		m.f = f
+		m.r = bufio.NewReader(f)
+	}
+
+	push := func(entries []interface{}) error {
+		if len(entries) == 0 {
+			return nil
+		}
+		return m.rdb.RPush(ctx, key, entries...).Err()
+	}
+
+	entries := []interface{}{}
+
+	for {
+		line, err := m.r.ReadBytes('\n')
+		if err == io.EOF {
+			// keep the incomplete entry until the rest of it is written
+			m.partial = append(m.partial, line...)
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(m.partial) > 0 {
+			line = append(m.partial, line...)
+			m.partial = nil
+		}
+
+		var e ManifestEntry
+		if err := json.Unmarshal(line, &e); err != nil {
+			log.Warnf("[%s] skip invalid manifest entry: %s", m.tid, err)
+			continue
+		}
+
+		entries = append(entries, string(bytes.TrimSpace(line)))
+		if len(entries) == 1000 {
+			if err := push(entries); err != nil {
+				return err
+			}
+			entries = entries[:0]
+		}
+	}
+
+	if err := push(entries); err != nil {
+		return err
+	}
+
+	return m.rdb.Expire(ctx, key, m.retention).Err()
+}
@@ -0,0 +1,80 @@
+package tasks
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Metadata is an iRODS attribute-value-unit triple attached to the uploaded data objects and
+// the top-level collection of the transfer.
+//
+// The value and the unit are templates of the `text/template` package, rendered with the
+// `MetadataFields` of the data object or collection, e.g. `{{.Path}}` or `{{.Size}}`.
+type Metadata struct {
+	Attribute string `json:"attribute"`
+	Value     string `json:"value"`
+	Unit      string `json:"unit,omitempty"`
+}
+
+// MetadataFields are the fields available to the templates of the `Metadata`.
+type MetadataFields struct {
+	// Path is the path relative to the top-level collection; empty for the top-level
+	// collection itself.
+	Path string
+	// Name is the name of the data object or collection.
+	Name string
+	// Size is the size of the data object in bytes, or the total size of the transferred
+	// files for the top-level collection.
+	Size int64
+	// ModTime is the modification time of the source file or directory.
+	ModTime time.Time
+}
+
+// Validate checks whether the attribute is set and the value and unit are valid templates.
+func (m Metadata) Validate() error {
+	if strings.TrimSpace(m.Attribute) == "" {
+		return fmt.Errorf("empty metadata attribute")
+	}
+	if m.Value == "" {
+		return fmt.Errorf("empty value of metadata attribute %s", m.Attribute)
+	}
+	for _, t := range []string{m.Value, m.Unit} {
+		if _, err := template.New(m.Attribute).Parse(t); err != nil {
+			return fmt.Errorf("invalid template of metadata attribute %s: %w", m.Attribute, err)
+		}
+	}
+	return nil
+}
+
+// Render returns the metadata with the value and the unit rendered with the fields `f`.
+func (m Metadata) Render(f MetadataFields) (Metadata, error) {
+
+	render := func(t string) (string, error) {
+		tmpl, err := template.New(m.Attribute).Parse(t)
+		if err != nil {
+			return "", err
+		}
+		var b strings.Builder
+		if err := tmpl.Execute(&b, f); err != nil {
+			return "", err
+		}
+		return b.String(), nil
+	}
+
+	v, err := render(m.Value)
+	if err != nil {
+		return m, fmt.Errorf("cannot render value of metadata attribute %s: %w", m.Attribute, err)
+	}
+	if v == "" {
+		return m, fmt.Errorf("empty value of metadata attribute %s for %s", m.Attribute, f.Path)
+	}
+
+	u, err := render(m.Unit)
+	if err != nil {
+		return m, fmt.Errorf("cannot render unit of metadata attribute %s: %w", m.Attribute, err)
+	}
+
+	return Metadata{Attribute: m.Attribute, Value: v, Unit: u}, nil
+}
@@ -0,0 +1,65 @@
+package tasks
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMetadataRender(t *testing.T) {
+
+	f := MetadataFields{
+		Path:    "sub-01/meg/run1.ds",
+		Name:    "run1.ds",
+		Size:    1024,
+		ModTime: time.Date(2024, 3, 1, 10, 30, 0, 0, time.UTC),
+	}
+
+	cases := []struct {
+		m        Metadata
+		expected Metadata
+	}{
+		{
+			Metadata{Attribute: "project", Value: "3010000.01"},
+			Metadata{Attribute: "project", Value: "3010000.01"},
+		},
+		{
+			Metadata{Attribute: "relpath", Value: "{{.Path}}"},
+			Metadata{Attribute: "relpath", Value: "sub-01/meg/run1.ds"},
+		},
+		{
+			Metadata{Attribute: "size", Value: "{{.Size}}", Unit: "bytes"},
+			Metadata{Attribute: "size", Value: "1024", Unit: "bytes"},
+		},
+		{
+			Metadata{Attribute: "acquired", Value: `{{.ModTime.Format "2006-01-02"}}`},
+			Metadata{Attribute: "acquired", Value: "2024-03-01"},
+		},
+	}
+
+	for _, c := range cases {
+		if err := c.m.Validate(); err != nil {
+			t.Fatalf("%s\n", err)
+		}
+		r, err := c.m.Render(f)
+		if err != nil {
+			t.Fatalf("%s\n", err)
+		}
+		if r != c.expected {
+			t.Errorf("expect %+v, got %+v", c.expected, r)
+		}
+	}
+
+	for _, m := range []Metadata{
+		{Attribute: "", Value: "x"},
+		{Attribute: "a", Value: ""},
+		{Attribute: "a", Value: "{{.Path"},
+	} {
+		if err := m.Validate(); err == nil {
+			t.Errorf("expect invalid metadata: %+v", m)
+		}
+	}
+
+	if _, err := (Metadata{Attribute: "a", Value: "{{.Subject}}"}).Render(f); err == nil {
+		t.Errorf("expect error on unknown field")
+	}
+}
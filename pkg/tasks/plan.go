@@ -0,0 +1,64 @@
+package tasks
+
+import "github.com/dccn-tg/dr-data-stager/pkg/event"
+
+// Actions planned on a file by a plan-only task.
+const (
+	ActionCopy      = "copy"
+	ActionOverwrite = "overwrite"
+	ActionSkip      = "skip"
+	ActionDelete    = "delete"
+)
+
+// PlanSampleSize is the maximum number of planned actions kept in the sample of the plan.
+var PlanSampleSize = 100
+
+// PlanResult is the summary of the actions planned by a plan-only task.
+type PlanResult struct {
+	// number of files to be copied to the destination
+	Copy int64 `json:"copy"`
+
+	// number of files at the destination to be overwritten
+	Overwrite int64 `json:"overwrite"`
+
+	// number of files skipped as they are identical at the destination
+	Skip int64 `json:"skip"`
+
+	// number of files to be removed from the destination in the mirror mode
+	Delete int64 `json:"delete"`
+
+	// total size in bytes of the files to be copied or overwritten
+	Bytes int64 `json:"bytes"`
+
+	// the first `PlanSampleSize` planned actions
+	Sample []ManifestEntry `json:"sample"`
+}
+
+// add counts the action planned on a file, reported by `s-isync` with the event `evt`.
+func (r *PlanResult) add(evt event.Event) {
+	if r == nil {
+		return
+	}
+
+	switch evt.Action {
+	case ActionCopy:
+		r.Copy++
+		r.Bytes += evt.Size
+	case ActionOverwrite:
+		r.Overwrite++
+		r.Bytes += evt.Size
+	case ActionSkip:
+		r.Skip++
+	case ActionDelete:
+		r.Delete++
+	}
+
+	if len(r.Sample) < PlanSampleSize {
+		r.Sample = append(r.Sample, ManifestEntry{
+			Path:    evt.File,
+			DstPath: evt.DstFile,
+			Size:    evt.Size,
+			Action:  evt.Action,
+		})
+	}
+}
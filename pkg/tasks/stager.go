@@ -4,18 +4,23 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"os/user"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
 	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
 
 	"github.com/dccn-tg/dr-data-stager/internal/worker/config"
+	ierrors "github.com/dccn-tg/dr-data-stager/pkg/errors"
+	"github.com/dccn-tg/dr-data-stager/pkg/event"
 	"github.com/dccn-tg/dr-data-stager/pkg/utility"
 	log "github.com/dccn-tg/tg-toolset-golang/pkg/logger"
 )
@@ -23,6 +28,9 @@ import (
 // A list of task types.
 const (
 	TypeStager = "stager"
+	// TypePlan is the type of the plan-only task, which reports the actions of
+	// the stager task with the same payload without moving data.
+	TypePlan = "plan"
 )
 
 // Queues for different task types, with their associated task priority
@@ -66,40 +74,111 @@ type StagerPayload struct {
 
 	// allowed duration in seconds for no further transfer progress (0 for no timeout)
 	TimeoutNoprogress int64 `json:"timeout_noprogress,omitempty"`
+
+	// duration in seconds for which the finished job and its transfer manifest are kept (0 for `DefaultRetention`)
+	Retention int64 `json:"retention,omitempty"`
+
+	// continue with remaining files when a file fails to be transferred
+	ContinueOnError bool `json:"continueOnError,omitempty"`
+
+	// verify each transferred file by comparing the checksum of the source and the destination
+	Verify bool `json:"verify,omitempty"`
+
+	// remove files at the destination that are not present at the source
+	Mirror bool `json:"mirror,omitempty"`
+
+	// maximum number of files allowed to be removed in the mirror mode (0 for `DefaultMaxDeletions`)
+	MaxDeletions int64 `json:"maxDeletions,omitempty"`
+
+	// only transfer files matching one of the glob patterns
+	Include []string `json:"include,omitempty"`
+
+	// skip files and directories matching one of the glob patterns
+	Exclude []string `json:"exclude,omitempty"`
+
+	// skip files smaller than the size in bytes
+	MinSize int64 `json:"minSize,omitempty"`
+
+	// skip files larger than the size in bytes (0 for no limit)
+	MaxSize int64 `json:"maxSize,omitempty"`
+
+	// handling of symbolic links in a local source directory: skip, follow or record
+	Symlinks string `json:"symlinks,omitempty"`
+
+	// bandwidth limit of the transfer in bytes per second (0 for no limit)
+	BandwidthLimit int64 `json:"bandwidthLimit,omitempty"`
+
+	// iRODS metadata attached to the uploaded data objects and the top-level collection
+	Metadata []Metadata `json:"metadata,omitempty"`
+
+	// upload files smaller than the size in bytes in tar bundles (0 for no bundling)
+	BundleThreshold int64 `json:"bundleThreshold,omitempty"`
+
+	// maximum size in bytes of the files in a tar bundle (0 for `DefaultBundleSize`)
+	BundleSize int64 `json:"bundleSize,omitempty"`
+
+	// unpack the tar bundles when downloading from iRODS
+	Unpack bool `json:"unpack,omitempty"`
+
+	// strategy to detect identical files to be skipped: checksum, size-mtime or always
+	Compare string `json:"compare,omitempty"`
+
+	// handling of files existing at the destination that differ: overwrite, skip, rename or fail
+	Conflict string `json:"conflict,omitempty"`
+}
+
+// DefaultMaxDeletions is the default maximum number of files allowed to be removed
+// from the destination in the mirror mode.
+const DefaultMaxDeletions = 1000
+
+// DefaultBundleSize is the default maximum size in bytes of the files in a tar bundle.
+const DefaultBundleSize = 256 << 20
+
+// retention returns the duration for which the finished job and its transfer manifest are kept.
+func (p StagerPayload) retention() time.Duration {
+	if p.Retention <= 0 {
+		return DefaultRetention
+	}
+	return time.Duration(p.Retention) * time.Second
 }
 
 // NewStagerTask wraps payload data into a `asynq.Task` ready for enqueuing.
-func NewStagerTask(Title, DrUser, DrPass, DstURL, SrcURL, StagerUser, StagerUserEmail string, Timeout, TimeoutNoprogress int64) (*asynq.Task, error) {
-	payload, err := json.Marshal(StagerPayload{
-		CreatedAt:         time.Now().Unix(),
-		Title:             Title,
-		DrUser:            DrUser,
-		DrPass:            DrPass,
-		DstURL:            DstURL,
-		SrcURL:            SrcURL,
-		StagerUser:        StagerUser,
-		StagerUserEmail:   StagerUserEmail,
-		Timeout:           Timeout,
-		TimeoutNoprogress: TimeoutNoprogress,
-	})
+//
+// The creation time of the payload is set to the current time.
+func NewStagerTask(p StagerPayload) (*asynq.Task, error) {
+	return newTask(TypeStager, p)
+}
+
+// NewPlanTask wraps payload data into a plan-only `asynq.Task` ready for enqueuing.
+//
+// The creation time of the payload is set to the current time.
+func NewPlanTask(p StagerPayload) (*asynq.Task, error) {
+	return newTask(TypePlan, p)
+}
+
+func newTask(typename string, p StagerPayload) (*asynq.Task, error) {
+	p.CreatedAt = time.Now().Unix()
+	payload, err := json.Marshal(p)
 	if err != nil {
 		return nil, err
 	}
 	// task options are default settings which can be overridden at enqueue time.
 	return asynq.NewTask(
-		TypeStager,
+		typename,
 		payload,
 		asynq.MaxRetry(2),
-		asynq.Timeout(time.Duration(Timeout)*time.Second),
+		asynq.Timeout(time.Duration(p.Timeout)*time.Second),
 	), nil
 }
 
 // Stager implements asynq.Handler interface.
 type Stager struct {
-	config config.Configuration
+	config    config.Configuration
+	rdb       *redis.Client
+	bandwidth *bandwidthShare
 }
 
-func (stager *Stager) ProcessTask(ctx context.Context, t *asynq.Task) error {
+func (stager *Stager) ProcessTask(ctx context.Context, t *asynq.Task) (err error) {
 
 	updateRslt := func(rslt *StagerTaskResult) {
 		if d, err := json.Marshal(rslt); err == nil {
@@ -121,29 +200,115 @@ func (stager *Stager) ProcessTask(ctx context.Context, t *asynq.Task) error {
 
 	timer := time.NewTimer(time.Duration(p.TimeoutNoprogress) * time.Second)
 
-	cout, cerr, cmd, err := runSyncAs(ctx, p, stager.config.Process.Concurrency, stager.config.Process.Verbose)
+	// the state of the task is kept for the next retry, unless the task is
+	// finished or there is no retry left.
+	stateDir := taskStateDir(stager.config.Process.StateDir, tid)
+	defer func() {
+		if err != nil && !errors.Is(err, asynq.SkipRetry) && !isLastAttempt(ctx) {
+			log.Debugf("[%s] keep task state for retry: %s", tid, stateDir)
+			return
+		}
+		if err := os.RemoveAll(stateDir); err != nil {
+			log.Errorf("[%s] fail to remove task state: %s", tid, err)
+		}
+	}()
+
+	// plan-only task runs s-isync in the dry-run mode
+	dryRun := t.Type() == TypePlan
+
+	// the bandwidth of the worker is shared with other running tasks via the stdin of s-isync.
+	bwctl, bwlimit, err := stager.bandwidth.Join(tid, p.BandwidthLimit)
 	if err != nil {
 		log.Errorf("[%s] %s", tid, err)
 		return err
 	}
+	defer stager.bandwidth.Leave(tid)
+
+	cout, cerr, cmd, err := runSyncAs(ctx, p, stager.config.Process, stateDir, dryRun, bwlimit, bwctl)
+	bwctl.Close()
+	if err != nil {
+		log.Errorf("[%s] %s", tid, err)
+		return err
+	}
+
+	// keep the transfer manifest with the task, regardless of the outcome of s-isync.
+	manifest := newManifestStore(stager.rdb, tid, manifestFile(tid), p.retention())
+	defer func() {
+		if err := manifest.Close(); err != nil {
+			log.Errorf("[%s] fail to store transfer manifest: %s", tid, err)
+		}
+	}()
 
 	// updata task progress
 	done := make(chan error, 1)
 	go func() {
 		rslt := new(StagerTaskResult)
+		if dryRun {
+			rslt.Plan = new(PlanResult)
+		}
 
 		percent := 0
+		lastUpdate := time.Now()
+		meter := newRateMeter(time.Now())
 
-		for progress := range cout {
-			// stop timer
+		for evt := range cout {
+			// stop timer; any event from s-isync, including the heartbeat, is
+			// considered as a sign of progress.
 			if !timer.Stop() {
 				<-timer.C
 			}
 
+			rslt.Conflicts.add(evt)
+
+			switch evt.Type {
+			case event.TypePhase:
+				if evt.Phase == event.PhaseVerifying {
+					log.Debugf("[%s] s-isync verifies: %s -> %s", tid, evt.File, evt.DstFile)
+					break
+				}
+				log.Debugf("[%s] s-isync enters phase: %s", tid, evt.Phase)
+			case event.TypeFileError:
+				log.Warnf("[%s] fail to sync %s: %s", tid, evt.File, evt.Error)
+			case event.TypeFileDeleted:
+				log.Debugf("[%s] deleted: %s", tid, evt.File)
+				rslt.Deleted++
+				if len(rslt.DeletedPaths) < DeletedPathsSize {
+					rslt.DeletedPaths = append(rslt.DeletedPaths, evt.File)
+				}
+			case event.TypeFilePlanned:
+				rslt.Plan.add(evt)
+			case event.TypeLinkSkipped:
+				log.Debugf("[%s] skipped symlink: %s", tid, evt.File)
+				rslt.SkippedLinks++
+			case event.TypeSummary:
+				log.Debugf("[%s] s-isync summary: %+v, error: %s", tid, evt.Progress, evt.Error)
+			}
+
+			// only events with progress data lead to update of the task result
+			if evt.Progress == nil {
+				// reset timer
+				timer.Reset(time.Duration(p.TimeoutNoprogress) * time.Second)
+				continue
+			}
+
+			progress := evt.Progress
+
 			// increase the counter by 1, and update the queue data
 			rslt.Progress.Total = progress.Total
 			rslt.Progress.Processed = progress.Success + progress.Failure
 			rslt.Progress.Failed = progress.Failure
+			rslt.Progress.TotalBytes = progress.TotalBytes
+			rslt.Progress.ProcessedBytes = progress.DoneBytes
+			rslt.Progress.Provisional = progress.Provisional
+
+			// update transfer rate and estimated time of completion; the time of
+			// completion is unknown as long as the total is provisional.
+			now := time.Now()
+			rslt.Progress.Rate = meter.Update(now, progress.DoneBytes)
+			rslt.Progress.ETA = 0
+			if remain := progress.TotalBytes - progress.DoneBytes; rslt.Progress.Rate > 0 && remain > 0 && !progress.Provisional {
+				rslt.Progress.ETA = now.Add(time.Duration(remain/rslt.Progress.Rate) * time.Second).Unix()
+			}
 
 			// skip this progress data when `progres.Total` is `0`
 			if progress.Total == 0 {
@@ -152,19 +317,40 @@ func (stager *Stager) ProcessTask(ctx context.Context, t *asynq.Task) error {
 				continue
 			}
 
-			npercent := int(100 * (progress.Success + progress.Failure) / progress.Total)
+			// percentage is based on bytes if the total size is known, otherwise on files.
+			// It is not evaluated against a provisional total, the task result is then
+			// updated at the `progressUpdateInterval`.
+			npercent := percent
+			switch {
+			case progress.Provisional:
+				// keep the percentage of the last update
+			case progress.TotalBytes > 0:
+				npercent = int(100 * progress.DoneBytes / progress.TotalBytes)
+			default:
+				npercent = int(100 * (progress.Success + progress.Failure) / progress.Total)
+			}
 
-			log.Debugf("[%s] %d/%d (%d%%) processed", tid, rslt.Progress.Processed, rslt.Progress.Total, npercent)
+			log.Debugf("[%s] %d/%d (%d%%) processed, %d bytes/s", tid, rslt.Progress.Processed, rslt.Progress.Total, npercent, rslt.Progress.Rate)
 
-			if npercent > percent {
+			if npercent > percent || now.Sub(lastUpdate) >= progressUpdateInterval {
 				updateRslt(rslt)
+				if err := manifest.Sync(); err != nil {
+					log.Errorf("[%s] fail to store transfer manifest: %s", tid, err)
+				}
 				percent = npercent
+				lastUpdate = now
 			}
 
 			// reset timer
 			timer.Reset(time.Duration(p.TimeoutNoprogress) * time.Second)
 		}
 
+		// make sure the final counters are stored with the task; there is no
+		// ongoing transfer anymore.
+		rslt.Progress.Rate = 0
+		rslt.Progress.ETA = 0
+		updateRslt(rslt)
+
 		// wait for command to stop
 		done <- cmd.Wait()
 	}()
@@ -183,6 +369,22 @@ func (stager *Stager) ProcessTask(ctx context.Context, t *asynq.Task) error {
 			}
 		case e := <-done:
 
+			// s-isync finished the transfer with some failed files, the task
+			// is considered as completed with failures and not retried.
+			var ee *exec.ExitError
+			if errors.As(e, &ee) && ee.ExitCode() == ierrors.ExitCodePartialSuccess {
+				log.Warnf("[%s] s-isync completed with failures: %s", tid, lastErr)
+				return nil
+			}
+
+			// the destination doesn't have enough space for the data, retrying the task
+			// won't help.
+			if errors.As(e, &ee) && ee.ExitCode() == ierrors.ExitCodeInsufficientSpace {
+				err := fmt.Errorf("s-isync failed: %s: %w", lastErr, asynq.SkipRetry)
+				log.Errorf("[%s] %s", tid, err)
+				return err
+			}
+
 			if e != nil {
 				err := fmt.Errorf("s-isync failed: %s - %s", e, lastErr)
 				log.Errorf("[%s] %s", tid, err)
@@ -217,21 +419,75 @@ func (stager *Stager) ProcessTask(ctx context.Context, t *asynq.Task) error {
 	}
 }
 
-// progress stores total number of processed files.
-type progress struct {
-	Total   int64
-	Success int64
-	Failure int64
+// progressUpdateInterval is the maximum interval at which the task result is updated
+// with the transfer rate and estimated time of completion.
+var progressUpdateInterval = 10 * time.Second
+
+// rateMeter estimates the transfer rate in bytes per second as an exponential moving
+// average of the rates measured between samples.
+type rateMeter struct {
+	rate  float64
+	bytes int64
+	time  time.Time
+}
+
+func newRateMeter(t time.Time) *rateMeter {
+	return &rateMeter{time: t}
+}
+
+// Update adds a sample of the accumulated number of bytes at time `t`, and returns
+// the estimated transfer rate.  Samples taken within a second after the previous
+// sample are ignored.
+func (m *rateMeter) Update(t time.Time, bytes int64) int64 {
+	dt := t.Sub(m.time).Seconds()
+	if dt < 1 {
+		return int64(m.rate)
+	}
+
+	r := float64(bytes-m.bytes) / dt
+	if m.rate == 0 {
+		m.rate = r
+	} else {
+		m.rate = 0.3*r + 0.7*m.rate
+	}
+
+	m.bytes = bytes
+	m.time = t
+
+	return int64(m.rate)
+}
+
+// taskStateDir returns the state directory of the task `tid` under the top-level
+// directory `root`.
+func taskStateDir(root, tid string) string {
+	return filepath.Join(root, tid)
+}
+
+// isLastAttempt checks whether the task in process has no retry left.
+func isLastAttempt(ctx context.Context) bool {
+	retried, _ := asynq.GetRetryCount(ctx)
+	maxRetry, _ := asynq.GetMaxRetry(ctx)
+	return retried >= maxRetry
 }
 
 // runSyncAs runs `s-isync` as the `stagerUser` in a go routine.
-func runSyncAs(ctx context.Context, payload StagerPayload, concurrency int, verbose bool) (chan progress, chan string, *exec.Cmd, error) {
+//
+// The `stateDir` is created, if it doesn't exist, and owned by the `stagerUser` for
+// `s-isync` to keep the checkpoint journal across retries of the task.  With `dryRun`,
+// `s-isync` only reports the planned actions without moving data.  The `bwlimit` is the
+// initial bandwidth limit of `s-isync`, and the `bwctl` is the stdin of `s-isync` from which
+// it reads the updated bandwidth limits.
+//
+// The events reported by `s-isync` on its stdout are returned via the first channel;
+// the lines on its stderr are returned via the second channel.
+func runSyncAs(ctx context.Context, payload StagerPayload, cfg config.ProcessConfiguration, stateDir string, dryRun bool, bwlimit int64, bwctl *os.File) (chan event.Event, chan string, *exec.Cmd, error) {
 
 	tid, ok := asynq.GetTaskID(ctx)
 	if !ok {
 		return nil, nil, nil, fmt.Errorf("invalid context: missing asynq task id")
 	}
 
+	concurrency := cfg.Concurrency
 	if concurrency == 0 {
 		concurrency = 1
 	}
@@ -243,12 +499,94 @@ func runSyncAs(ctx context.Context, payload StagerPayload, concurrency int, verb
 		"-p", strconv.Itoa(concurrency),
 		"--task", tid,
 		"--druser", payload.DrUser,
+		"--manifest", manifestFile(tid),
+		"--state", stateDir,
+		"--parallel-threshold", strconv.FormatInt(cfg.ParallelThreshold, 10),
+		"--parallel-threads", strconv.Itoa(cfg.ParallelThreads),
+		"--max-connections", strconv.Itoa(cfg.MaxConnections),
+		"--retries", strconv.Itoa(cfg.FileRetries),
+		"--retry-delay", fmt.Sprintf("%ds", cfg.FileRetryDelay),
 	}
 
-	if verbose {
+	if cfg.Verbose {
 		cmdArgs = append(cmdArgs, "-v")
 	}
 
+	if payload.ContinueOnError {
+		cmdArgs = append(cmdArgs, "--continue-on-error")
+	}
+
+	if payload.Verify {
+		cmdArgs = append(cmdArgs, "--verify")
+	}
+
+	if payload.Mirror {
+		maxDeletions := payload.MaxDeletions
+		if maxDeletions <= 0 {
+			maxDeletions = DefaultMaxDeletions
+		}
+		cmdArgs = append(cmdArgs, "--mirror", "--max-deletions", strconv.FormatInt(maxDeletions, 10))
+	}
+
+	for _, p := range payload.Include {
+		cmdArgs = append(cmdArgs, "--include", p)
+	}
+
+	for _, p := range payload.Exclude {
+		cmdArgs = append(cmdArgs, "--exclude", p)
+	}
+
+	if payload.MinSize > 0 {
+		cmdArgs = append(cmdArgs, "--min-size", strconv.FormatInt(payload.MinSize, 10))
+	}
+
+	if payload.MaxSize > 0 {
+		cmdArgs = append(cmdArgs, "--max-size", strconv.FormatInt(payload.MaxSize, 10))
+	}
+
+	if payload.Symlinks != "" {
+		cmdArgs = append(cmdArgs, "--symlinks", payload.Symlinks)
+	}
+
+	if payload.Compare != "" {
+		cmdArgs = append(cmdArgs, "--compare", payload.Compare)
+	}
+
+	if payload.Conflict != "" {
+		cmdArgs = append(cmdArgs, "--conflict", payload.Conflict)
+	}
+
+	if payload.BundleThreshold > 0 {
+		bundleSize := payload.BundleSize
+		if bundleSize <= 0 {
+			bundleSize = DefaultBundleSize
+		}
+		cmdArgs = append(cmdArgs,
+			"--bundle-threshold", strconv.FormatInt(payload.BundleThreshold, 10),
+			"--bundle-size", strconv.FormatInt(bundleSize, 10),
+		)
+	}
+
+	if payload.Unpack {
+		cmdArgs = append(cmdArgs, "--unpack")
+	}
+
+	for _, m := range payload.Metadata {
+		data, err := json.Marshal(m)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("invalid metadata %+v: %s", m, err)
+		}
+		cmdArgs = append(cmdArgs, "--metadata", string(data))
+	}
+
+	if dryRun {
+		cmdArgs = append(cmdArgs, "--dry-run")
+	}
+
+	if bwctl != nil {
+		cmdArgs = append(cmdArgs, "--bwlimit", strconv.FormatInt(bwlimit, 10), "--bwlimit-stdin")
+	}
+
 	u, err := user.Lookup(payload.StagerUser)
 	if err != nil {
 		return nil, nil, nil, err
@@ -257,6 +595,15 @@ func runSyncAs(ctx context.Context, payload StagerPayload, concurrency int, verb
 	uid, _ := strconv.ParseInt(u.Uid, 10, 32)
 	gid, _ := strconv.ParseInt(u.Gid, 10, 32)
 
+	// prepare the state directory
+	if err := os.MkdirAll(stateDir, 0700); err != nil {
+		return nil, nil, nil, fmt.Errorf("fail to create state directory %s: %s", stateDir, err)
+	}
+
+	if err := os.Chown(stateDir, int(uid), int(gid)); err != nil {
+		return nil, nil, nil, fmt.Errorf("fail to change owner of state directory %s: %s", stateDir, err)
+	}
+
 	if payload.StagerUser != "root" {
 
 		// for non-privileged stager user, decrypted the credential in task payload and pass it
@@ -301,6 +648,9 @@ func runSyncAs(ctx context.Context, payload StagerPayload, concurrency int, verb
 	cmd := exec.Command("/opt/stager/s-isync", cmdArgs...)
 	cmd.SysProcAttr = &syscall.SysProcAttr{}
 	cmd.SysProcAttr.Credential = &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid)}
+	if bwctl != nil {
+		cmd.Stdin = bwctl
+	}
 
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
@@ -317,16 +667,31 @@ func runSyncAs(ctx context.Context, payload StagerPayload, concurrency int, verb
 		return nil, nil, cmd, err
 	}
 
-	cout := make(chan progress, 1)
-	// go routine to read and process the stdout of s-irsync (progress information)
+	cout := make(chan event.Event, 1)
+	// go routine to read and process the stdout of s-irsync (events)
 	go func() {
 		defer close(cout)
 		scanner := bufio.NewScanner(stdout)
 		for scanner.Scan() {
 			line := strings.TrimSpace(scanner.Text())
+
+			// JSON-lines events
+			if strings.HasPrefix(line, "{") {
+				evt, err := event.Parse([]byte(line))
+				if err != nil {
+					log.Errorf("cannot parse event output: %s, %s", line, err)
+					continue
+				}
+				cout <- *evt
+				continue
+			}
+
+			// legacy CSV progress output of older s-isync
 			data := strings.Split(line, ",")
 
-			if len(data) != 3 {
+			// progress output is either `total,success,failure` or
+			// `total,success,failure,bytesTotal,bytesDone`.
+			if len(data) != 3 && len(data) != 5 {
 				log.Errorf("unexpected progress output: %s", string(line))
 				continue
 			}
@@ -349,10 +714,29 @@ func runSyncAs(ctx context.Context, payload StagerPayload, concurrency int, verb
 				continue
 			}
 
-			cout <- progress{
-				Total:   t,
-				Success: s,
-				Failure: f,
+			var bt, bd int64
+			if len(data) == 5 {
+				if bt, err = strconv.ParseInt(data[3], 10, 64); err != nil {
+					log.Errorf("cannot parse progress output for total bytes: %s, %s", data[3], err)
+					continue
+				}
+
+				if bd, err = strconv.ParseInt(data[4], 10, 64); err != nil {
+					log.Errorf("cannot parse progress output for processed bytes: %s, %s", data[4], err)
+					continue
+				}
+			}
+
+			cout <- event.Event{
+				Version: event.Version,
+				Type:    event.TypeProgress,
+				Progress: &event.Progress{
+					Total:      t,
+					Success:    s,
+					Failure:    f,
+					TotalBytes: bt,
+					DoneBytes:  bd,
+				},
 			}
 		}
 	}()
@@ -372,17 +756,49 @@ func runSyncAs(ctx context.Context, payload StagerPayload, concurrency int, verb
 	return cout, cerr, cmd, nil
 }
 
-func NewStager(config config.Configuration) *Stager {
+// NewStager creates a new Stager. The redis client `rdb` is used for storing
+// the transfer manifest of the tasks.
+func NewStager(config config.Configuration, rdb *redis.Client) *Stager {
 	return &Stager{
-		config: config,
+		config:    config,
+		rdb:       rdb,
+		bandwidth: newBandwidthShare(config.Process.BandwidthLimit),
 	}
 }
 
+// DeletedPathsSize is the maximum number of removed paths kept in the result of a task.
+var DeletedPathsSize = 100
+
 // StagerTaskResult
 type StagerTaskResult struct {
 	Progress struct {
-		Total     int64 `json:"total"`
-		Processed int64 `json:"processes"`
-		Failed    int64 `json:"failed"`
+		Total          int64 `json:"total"`
+		Processed      int64 `json:"processes"`
+		Failed         int64 `json:"failed"`
+		TotalBytes     int64 `json:"totalBytes"`
+		ProcessedBytes int64 `json:"processedBytes"`
+		// transfer rate in bytes per second
+		Rate int64 `json:"rate"`
+		// estimated time of completion as unix timestamp, 0 if unknown
+		ETA int64 `json:"eta"`
+		// the totals are provisional as the scan of the source is in progress
+		Provisional bool `json:"provisional,omitempty"`
 	} `json:"progress"`
+
+	// number of files removed from the destination in the mirror mode, the paths are
+	// kept in the transfer manifest
+	Deleted int64 `json:"deleted,omitempty"`
+
+	// the first `DeletedPathsSize` paths removed from the destination in the mirror mode
+	DeletedPaths []string `json:"deletedPaths,omitempty"`
+
+	// number of symbolic links in the source skipped by the symlink policy, the links are
+	// kept in the transfer manifest
+	SkippedLinks int64 `json:"skippedLinks,omitempty"`
+
+	// summary of the planned actions of a plan-only task
+	Plan *PlanResult `json:"plan,omitempty"`
+
+	// numbers of files existing at the destination handled by the conflict policy
+	Conflicts ConflictResult `json:"conflicts"`
 }
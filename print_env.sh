@@ -27,6 +27,9 @@ echo
 echo "# Stager task database persistent store"
 echo "TASK_DB_REDIS_DATA=$TASK_DB_REDIS_DATA"
 echo
+echo "# Worker state persistent store"
+echo "WORKER_STATE_VOL=$WORKER_STATE_VOL"
+echo
 echo "# API server configuration file"
 echo "API_CONFIG=$API_CONFIG"
 echo